@@ -0,0 +1,170 @@
+package form
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type benchAddress struct {
+	Street string `form:"street"`
+	City   string `form:"city" maxlen:"20"`
+	Zip    string `form:"zip" regex:"^[0-9]{5}$"`
+}
+
+type BenchMeta struct {
+	Source string `form:"source"`
+	Ref    int    `form:"ref"`
+}
+
+type benchInput struct {
+	BenchMeta
+	Name    string       `form:"name,required" minlen:"1"`
+	Age     uint8        `form:"age" min:"18"`
+	Score   float64      `form:"score" max:"100"`
+	Active  bool         `form:"active"`
+	Colour  string       `form:"colour" oneof:"red green blue"`
+	Limit   *int         `form:"limit"`
+	Tags    []string     `form:"tags" maxcount:"5"`
+	IDs     []int        `form:"ids"`
+	Address benchAddress `form:"address"`
+	Rows    []Row        `form:"rows"`
+}
+
+var (
+	benchValues = url.Values{
+		"source":         []string{"web"},
+		"ref":            []string{"12"},
+		"name":           []string{"Alice"},
+		"age":            []string{"30"},
+		"score":          []string{"99.5"},
+		"active":         []string{"on"},
+		"colour":         []string{"green"},
+		"tags":           []string{"a", "b", "c"},
+		"ids":            []string{"1", "2", "3", "4"},
+		"address.street": []string{"1 High Street"},
+		"address.city":   []string{"London"},
+		"address.zip":    []string{"12345"},
+	}
+	benchErrorValues = url.Values{
+		"ref":         []string{"x"},
+		"age":         []string{"10"},
+		"colour":      []string{"pink"},
+		"ids":         []string{"1", "x"},
+		"address.zip": []string{"1"},
+	}
+	benchRowValues = url.Values{
+		"name":         []string{"Alice"},
+		"rows[0].name": []string{"a"},
+		"rows[0].qty":  []string{"1"},
+		"rows[1].name": []string{"b"},
+		"rows[1].qty":  []string{"2"},
+	}
+)
+
+func TestProcessValuesAllocs(t *testing.T) {
+	var output benchInput
+
+	if err := ProcessValues(benchValues, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() {
+		ProcessValues(benchValues, &output)
+	}); allocs != 0 {
+		t.Errorf("expecting no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkProcessValues(b *testing.B) {
+	var output benchInput
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		if err := ProcessValues(benchValues, &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessValuesErrors(b *testing.B) {
+	var output benchInput
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		if err := ProcessValues(benchErrorValues, &output); err == nil {
+			b.Fatal("expecting error")
+		}
+	}
+}
+
+func BenchmarkProcessValuesRows(b *testing.B) {
+	var output benchInput
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		if err := ProcessValues(benchRowValues, &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessValuesParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		var output benchInput
+
+		for pb.Next() {
+			if err := ProcessValues(benchValues, &output); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkProcess(b *testing.B) {
+	var output benchInput
+
+	target := "/?" + benchValues.Encode()
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		if err := Process(httptest.NewRequest("GET", target, nil), &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBinder(b *testing.B) {
+	binder := MustBinder[benchInput](nil)
+
+	var output benchInput
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		if err := binder.ProcessValues(benchValues, &output); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	var input benchInput
+
+	if err := ProcessValues(benchValues, &input); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		if _, err := Encode(&input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package form
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// Binder processes form data into values of the struct type T, using type
+// information built once when the Binder is created.
+type Binder[T any] struct {
+	dec *Decoder
+	ti  *typeInfo
+}
+
+// NewBinder creates a Binder for the struct type T, which uses the options of
+// the given Decoder, or of the default Decoder if nil.
+//
+// Unlike Process, which ignores invalid tags, NewBinder returns any problems
+// with the tags of the fields of T, such as an invalid regular expression,
+// 'min' and 'max' values that cannot be parsed for the type of the field, or
+// fields of a type that cannot be processed, as *TagError errors, joined with
+// errors.Join. Fields that should not be processed can be skipped with a '-'
+// form tag.
+func NewBinder[T any](d *Decoder) (*Binder[T], error) {
+	if d == nil {
+		d = defaultDecoder
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, ErrNeedStruct
+	}
+
+	ti, err := d.getTypeInfo(t)
+	if err != nil {
+		return nil, err
+	} else if ti.tagErr != nil {
+		return nil, ti.tagErr
+	}
+
+	return &Binder[T]{
+		dec: d,
+		ti:  ti,
+	}, nil
+}
+
+// MustBinder acts like NewBinder, but panics if there is an error, for use
+// when initialising global variables.
+func MustBinder[T any](d *Decoder) *Binder[T] {
+	b, err := NewBinder[T](d)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// Decode parses the form data from the request into a new T.
+//
+// As with Process, the returned value contains all of the fields that were
+// successfully processed, even when an error is returned.
+func (b *Binder[T]) Decode(r *http.Request) (T, error) {
+	var v T
+
+	err := b.Process(r, &v)
+
+	return v, err
+}
+
+// DecodeValues parses the given values into a new T, as with ProcessValues.
+func (b *Binder[T]) DecodeValues(vals url.Values) (T, error) {
+	var v T
+
+	err := b.ProcessValues(vals, &v)
+
+	return v, err
+}
+
+// Process parses the form data from the request into the given T.
+func (b *Binder[T]) Process(r *http.Request, v *T) error {
+	vals, err := b.dec.requestValues(r, nil)
+	if err != nil {
+		return err
+	}
+
+	return b.dec.processStruct(reflect.ValueOf(v).Elem(), b.ti, vals)
+}
+
+// ProcessValues parses the given values into the given T, as with
+// ProcessValues.
+func (b *Binder[T]) ProcessValues(vals url.Values, v *T) error {
+	return b.dec.processStruct(reflect.ValueOf(v).Elem(), b.ti, values{
+		form:  vals,
+		post:  vals,
+		query: vals,
+	})
+}
+
+var binders sync.Map
+
+// Decode parses the form data from the request into a new T, which must be a
+// struct type, using a Binder with the default Decoder that is created on the
+// first call for each type.
+//
+// Any error from creating the Binder, see NewBinder, is returned from every
+// call.
+func Decode[T any](r *http.Request) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	b, ok := binders.Load(t)
+	if !ok {
+		nb, err := NewBinder[T](nil)
+		if err != nil {
+			var v T
+
+			return v, err
+		}
+
+		b, _ = binders.LoadOrStore(t, nb)
+	}
+
+	return b.(*Binder[T]).Decode(r)
+}
@@ -0,0 +1,128 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"regexp/syntax"
+	"strconv"
+	"testing"
+)
+
+func TestBinder(t *testing.T) {
+	type input struct {
+		A int      `form:"a" min:"1"`
+		B []string `form:"b" maxcount:"2"`
+		C Row      `form:"c"`
+		D chan int `form:"-"`
+	}
+
+	b, err := NewBinder[input](nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	output, err := b.DecodeValues(url.Values{
+		"a":      []string{"2"},
+		"b":      []string{"x", "y"},
+		"c.name": []string{"Alice"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := input{
+		A: 2,
+		B: []string{"x", "y"},
+		C: Row{Name: "Alice"},
+	}
+
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("expecting output %#v, got %#v", expected, output)
+	}
+
+	_, err = Decode[input](newRequest(url.Values{"a": []string{"0"}}, url.Values{}))
+	if expectedErr := (ErrorMap{
+		"a":      &RangeError{Min: int64(1), Value: int64(0)},
+		"c.name": ErrRequiredMissing,
+	}); !reflect.DeepEqual(err, expectedErr) {
+		t.Errorf("expecting error %v, got %v", expectedErr, err)
+	}
+
+	if _, err := NewBinder[int](nil); err != ErrNeedStruct {
+		t.Errorf("expecting error %s, got %v", ErrNeedStruct, err)
+	}
+}
+
+func TestBinderTagErrors(t *testing.T) {
+	type inner struct {
+		E uint `max:"-1"`
+	}
+
+	type input struct {
+		A string          `regex:"("`
+		B int8            `min:"-200"`
+		C chan int        `form:"c"`
+		D []inner         `maxindex:"x"`
+		F map[string]bool `maxkeys:"-1"`
+		G int             `oneof:"1 two"`
+		H inner
+	}
+
+	_, err := NewBinder[input](NewDecoder())
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+
+	fields := make(map[string]string)
+
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var te *TagError
+
+		if !errors.As(e, &te) {
+			t.Errorf("expecting TagError, got %v", e)
+
+			continue
+		}
+
+		fields[te.Type.Name()+"."+te.Field] = te.Tag
+	}
+
+	expected := map[string]string{
+		"input.A": "regex",
+		"input.B": "min",
+		"input.C": "form",
+		"input.D": "maxindex",
+		"inner.E": "max",
+		"input.F": "maxkeys",
+		"input.G": "oneof",
+	}
+
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expecting tag errors %v, got %v", expected, fields)
+	}
+
+	var se *syntax.Error
+
+	if !errors.As(err, &se) {
+		t.Errorf("expecting regex error, got %v", err)
+	}
+
+	if !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expecting range error, got %v", err)
+	}
+
+	var output input
+
+	if err := ProcessValues(url.Values{"B": []string{"-100"}}, &output); err != nil || output.B != -100 {
+		t.Errorf("expecting Process to ignore invalid tags, got %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expecting MustBinder to panic")
+		}
+	}()
+
+	MustBinder[input](nil)
+}
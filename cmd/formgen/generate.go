@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const formPath = "vimagination.zapto.org/form"
+
+var (
+	errNoTypes     = errors.New("no types given")
+	errNotFound    = errors.New("type not found")
+	errNotStruct   = errors.New("not a struct type")
+	errGeneric     = errors.New("generic types are not supported")
+	errUnsupported = errors.New("unsupported type")
+	errParser      = errors.New("pointers to types with a ParseForm method are not supported")
+	errMarshal     = errors.New("types with ParseForm and MarshalText methods, but no FormatForm method, are not supported")
+	errText        = errors.New("types with an UnmarshalText method are not supported")
+	errValidator   = errors.New("types with a ValidateForm method are not supported")
+	errTime        = errors.New("time types are not supported")
+	errPointer     = errors.New("pointers to pointers are not supported")
+)
+
+var (
+	errorType     = types.Universe.Lookup("error").Type()
+	stringsType   = types.NewSlice(types.Typ[types.String])
+	bytesType     = types.NewSlice(types.Typ[types.Byte])
+	parserIface   = newInterface("ParseForm", []types.Type{stringsType}, errorType)
+	formatIface   = newInterface("FormatForm", nil, stringsType)
+	textIface     = newInterface("UnmarshalText", []types.Type{bytesType}, errorType)
+	marshalIface  = newInterface("MarshalText", nil, bytesType, errorType)
+	validateIface = newInterface("ValidateForm", nil, errorType)
+)
+
+func newInterface(name string, params []types.Type, results ...types.Type) *types.Interface {
+	sig := types.NewSignatureType(nil, nil, nil, tuple(params), tuple(results), false)
+
+	return types.NewInterfaceType([]*types.Func{types.NewFunc(token.NoPos, nil, name, sig)}, nil).Complete()
+}
+
+func tuple(ts []types.Type) *types.Tuple {
+	vars := make([]*types.Var, len(ts))
+
+	for n, t := range ts {
+		vars[n] = types.NewParam(token.NoPos, nil, "", t)
+	}
+
+	return types.NewTuple(vars...)
+}
+
+func implements(t types.Type, i *types.Interface) bool {
+	return types.Implements(t, i) || types.Implements(types.NewPointer(t), i)
+}
+
+func isTime(t types.Type) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	if !ok || n.Obj().Pkg() == nil || n.Obj().Pkg().Path() != "time" {
+		return false
+	}
+
+	return n.Obj().Name() == "Time" || n.Obj().Name() == "Duration"
+}
+
+type source uint8
+
+const (
+	sourceForm source = iota
+	sourceQuery
+	sourceHeader
+	sourceCookie
+	sourcePath
+)
+
+type field struct {
+	key, path                 string
+	typ                       types.Type
+	spec                      *spec
+	ptr, slice, parser        bool
+	required, post, omitEmpty bool
+	src                       source
+}
+
+type fieldMap struct {
+	keys   []string
+	fields map[string]field
+}
+
+func (fm *fieldMap) set(f field) {
+	if _, ok := fm.fields[f.key]; !ok {
+		fm.keys = append(fm.keys, f.key)
+	}
+
+	fm.fields[f.key] = f
+}
+
+type fieldError struct {
+	typ, field string
+	err        error
+}
+
+func (f *fieldError) Error() string {
+	return f.typ + "." + f.field + ": " + f.err.Error()
+}
+
+func (f *fieldError) Unwrap() error {
+	return f.err
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string
+	regexps map[string]string
+	typ     string
+	buf     bytes.Buffer
+}
+
+func generate(pkg *types.Package, names []string) ([]byte, error) {
+	g := generator{
+		pkg:     pkg,
+		imports: map[string]string{"net/http": "http", "net/url": "url", formPath: "form"},
+	}
+
+	var body bytes.Buffer
+
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			return nil, &fieldError{typ: name, err: errNotFound}
+		}
+
+		n, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, &fieldError{typ: name, err: errNotStruct}
+		} else if n.TypeParams().Len() > 0 {
+			return nil, &fieldError{typ: name, err: errGeneric}
+		}
+
+		fm, err := g.collect(name, n)
+		if err != nil {
+			return nil, err
+		}
+
+		g.typ = name
+		g.regexps = make(map[string]string)
+		g.buf.Reset()
+
+		g.writeParse(fm)
+		g.writeEncode(fm)
+		g.writeRegexps()
+
+		body.Write(g.buf.Bytes())
+	}
+
+	var out bytes.Buffer
+
+	fmt.Fprintf(&out, "// Code generated by formgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg.Name())
+
+	paths := make([]string, 0, len(g.imports))
+
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for n, path := range paths {
+		if n > 0 && !strings.Contains(paths[n-1], ".") && strings.Contains(path, ".") {
+			out.WriteString("\n")
+		}
+
+		if name := g.imports[path]; name != lastElem(path) {
+			fmt.Fprintf(&out, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+func lastElem(path string) string {
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
+
+func hasOption(options, option string) bool {
+	return strings.Contains(options+",", ","+option+",")
+}
+
+func (g *generator) collect(typeName string, t types.Type) (*fieldMap, error) {
+	if implements(t, validateIface) {
+		return nil, &fieldError{typ: typeName, err: errValidator}
+	}
+
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, &fieldError{typ: typeName, err: errNotStruct}
+	}
+
+	fm := &fieldMap{fields: make(map[string]field)}
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+
+		var (
+			tags                             = reflect.StructTag(st.Tag(i))
+			name                             = f.Name()
+			required, post, omitEmpty, named bool
+			src                              source
+		)
+
+		if n := tags.Get("form"); n == "-" {
+			continue
+		} else if n != "" {
+			if p := strings.IndexByte(n, ','); p >= 0 {
+				if p > 0 {
+					name = n[:p]
+					named = true
+				}
+
+				rest := n[p:]
+				required = hasOption(rest, "required")
+				post = hasOption(rest, "post")
+				omitEmpty = hasOption(rest, "omitempty")
+
+				if hasOption(rest, "query") || hasOption(rest, "get") {
+					src = sourceQuery
+				} else if hasOption(rest, "header") {
+					src = sourceHeader
+				} else if hasOption(rest, "cookie") {
+					src = sourceCookie
+				} else if hasOption(rest, "path") {
+					src = sourcePath
+				}
+			} else {
+				name = n
+				named = true
+			}
+		}
+
+		fieldErr := func(err error) error {
+			return &fieldError{typ: typeName, field: f.Name(), err: err}
+		}
+
+		ft := f.Type()
+		fd := field{
+			key:       name,
+			path:      f.Name(),
+			typ:       ft,
+			required:  required,
+			post:      post,
+			omitEmpty: omitEmpty,
+			src:       src,
+		}
+
+		if implements(ft, parserIface) {
+			_, split := ft.Underlying().(*types.Slice)
+
+			if err := parserField(&fd, omitEmpty); err != nil {
+				return nil, fieldErr(err)
+			} else if fd.spec, err = newParserSpec(tags, false, split); err != nil {
+				return nil, fieldErr(err)
+			}
+
+			fm.set(fd)
+
+			continue
+		}
+
+		b, ptr, err := value(ft)
+		if err != nil {
+			return nil, fieldErr(err)
+		}
+
+		switch u := ft.Underlying().(type) {
+		case *types.Basic:
+			if b == nil {
+				return nil, fieldErr(errUnsupported)
+			}
+		case *types.Pointer:
+			if b == nil {
+				return nil, fieldErr(errUnsupported)
+			}
+
+			fd.typ = u.Elem()
+			fd.ptr = true
+		case *types.Slice:
+			if implements(u.Elem(), parserIface) {
+				fd.typ = u.Elem()
+				fd.slice = true
+
+				if err := parserField(&fd, false); err != nil {
+					return nil, fieldErr(err)
+				} else if fd.spec, err = newParserSpec(tags, true, true); err != nil {
+					return nil, fieldErr(err)
+				}
+
+				fm.set(fd)
+
+				continue
+			} else if b, ptr, err = value(u.Elem()); err != nil {
+				return nil, fieldErr(err)
+			} else if b == nil {
+				return nil, fieldErr(errUnsupported)
+			}
+
+			fd.typ = u.Elem()
+			fd.ptr = ptr
+			fd.slice = true
+
+			if ptr {
+				fd.typ = fd.typ.Underlying().(*types.Pointer).Elem()
+			}
+		case *types.Struct:
+			if _, ok := tags.Lookup("default"); ok {
+				return nil, fieldErr(&tagError{tag: "default", err: errUnsupported})
+			}
+
+			inner, err := g.collect(types.TypeString(ft, types.RelativeTo(g.pkg)), ft)
+			if err != nil {
+				return nil, err
+			}
+
+			if f.Anonymous() && !named {
+				for _, key := range inner.keys {
+					if _, ok := fm.fields[key]; !ok {
+						p := inner.fields[key]
+						p.path = f.Name() + "." + p.path
+
+						fm.set(p)
+					}
+				}
+			} else {
+				for _, key := range inner.keys {
+					p := inner.fields[key]
+					p.key = name + "." + key
+					p.path = f.Name() + "." + p.path
+					p.post = p.post || post
+
+					if p.src == sourceForm {
+						p.src = src
+					}
+
+					fm.set(p)
+				}
+			}
+
+			continue
+		default:
+			return nil, fieldErr(errUnsupported)
+		}
+
+		if fd.spec, err = newSpec(b, tags, fd.slice); err != nil {
+			return nil, fieldErr(err)
+		}
+
+		fm.set(fd)
+	}
+
+	return fm, nil
+}
+
+// parserField marks the field as being processed by the ParseForm method of
+// its type, and checks that the type can also be encoded in the same way as
+// form.Encode.
+func parserField(f *field, omitEmpty bool) error {
+	f.parser = true
+
+	if !implements(f.typ, formatIface) && implements(f.typ, marshalIface) {
+		return errMarshal
+	} else if !omitEmpty {
+		return nil
+	}
+
+	switch f.typ.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		if !types.Comparable(f.typ) {
+			return errUnsupported
+		}
+	}
+
+	return nil
+}
+
+// value returns the basic type that the given type is processed as, and
+// whether the type is a pointer to it, or nil if it is not processed as a
+// value.
+func value(t types.Type) (*types.Basic, bool, error) {
+	if implements(t, parserIface) {
+		return nil, false, errParser
+	} else if isTime(t) {
+		return nil, false, errTime
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		b, ptr, err := value(u.Elem())
+		if err != nil || b == nil {
+			return nil, false, err
+		} else if ptr {
+			return nil, false, errPointer
+		}
+
+		return b, true, nil
+	case *types.Basic:
+		if implements(t, textIface) {
+			return nil, false, errText
+		}
+
+		if isBasic(u) {
+			return u, false, nil
+		}
+	default:
+		if implements(t, textIface) {
+			return nil, false, errText
+		}
+	}
+
+	return nil, false, nil
+}
+
+func isBasic(b *types.Basic) bool {
+	switch b.Kind() {
+	case types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+		types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+		types.Float32, types.Float64, types.String, types.Bool:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+// Formgen generates methods for structs that parse and encode form data in the
+// same way as the form package, but without the use of reflection.
+//
+// For each of the named struct types, formgen writes a ParseFormRequest method,
+// which is used by form.Process in place of reflection, and an EncodeForm
+// method, which is likewise used by form.Encode.
+//
+// It is intended to be used with go generate, for example:
+//
+//	//go:generate go run vimagination.zapto.org/form/cmd/formgen -type Example
+//
+// The following flags are accepted:
+//
+//	-type    comma separated list of the struct types to generate methods for
+//	-output  name of the file to write; the default is <type>_form.go, where
+//	         <type> is the lower-case name of the first type
+//
+// An optional argument sets the directory of the package, which defaults to the
+// current directory.
+//
+// Only the options of a Decoder created without options are supported, and
+// the fields of the structs are limited to basic types, pointers to basic
+// types, types with a ParseForm method, slices of any of these, and nested and
+// embedded structs of the same. As with form.Process, types with a ParseForm
+// method are parsed with it, and are encoded with their FormatForm method, if
+// they have one, or otherwise by their kind. Fields with any other type, or
+// with types that have UnmarshalText or ValidateForm methods, are reported as
+// errors, as are tags with invalid values.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "formgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var typeNames, output string
+
+	flag.StringVar(&typeNames, "type", "", "comma separated list of struct types")
+	flag.StringVar(&output, "output", "", "output file name")
+	flag.Parse()
+
+	if typeNames == "" {
+		flag.Usage()
+
+		return errNoTypes
+	}
+
+	dir := "."
+
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	names := strings.Split(typeNames, ",")
+
+	if output == "" {
+		output = strings.ToLower(names[0]) + "_form.go"
+	}
+
+	output = filepath.Join(dir, output)
+
+	pkg, err := load(dir, output)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(pkg, names)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, src, 0o644)
+}
+
+func load(dir, skip string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fset  = token.NewFileSet()
+		files []*ast.File
+	)
+
+	for _, name := range bp.GoFiles {
+		path := filepath.Join(dir, name)
+		if path == filepath.Clean(skip) {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+
+	pkg, _ := conf.Check(bp.Name, fset, files, nil)
+
+	return pkg, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	const (
+		dir    = "../../internal/formgentest"
+		output = dir + "/types_form.go"
+	)
+
+	pkg, err := load(dir, output)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	src, err := generate(pkg, []string{"Basic", "Lists", "Sources", "Parsers"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(src, expected) {
+		t.Errorf("generated code does not match %s, run go generate", output)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	src := strings.ReplaceAll(`package types
+
+import "time"
+
+type Valid struct {
+	A int
+}
+
+type Validated struct {
+	A int
+}
+
+func (Validated) ValidateForm() error { return nil }
+
+type Parsed int
+
+func (*Parsed) ParseForm([]string) error { return nil }
+
+type Formatted struct{ A, B int }
+
+func (Formatted) ParseForm([]string) error { return nil }
+
+func (Formatted) FormatForm() []string { return nil }
+
+type Uncomparable struct{ A []int }
+
+func (Uncomparable) ParseForm([]string) error { return nil }
+
+type Marshaled int
+
+func (*Marshaled) ParseForm([]string) error { return nil }
+
+func (Marshaled) MarshalText() ([]byte, error) { return nil, nil }
+
+type Text struct{}
+
+func (*Text) UnmarshalText([]byte) error { return nil }
+
+type (
+	Chan      struct{ A chan int }
+	Map       struct{ A map[string]int }
+	Time      struct{ A time.Time }
+	Duration  struct{ A []time.Duration }
+	Structs   struct{ A []Valid }
+	Pointers  struct{ A **int }
+	Parsers   struct {
+		A Parsed
+		B []Parsed 'mincount:"1" default:"1"'
+		C *Parsed
+		D Formatted 'form:",omitempty"'
+		E []*Formatted
+	}
+	Parser    struct{ A **Parsed }
+	Marshaler struct{ A []Marshaled }
+	OmitEmpty struct{ A Uncomparable 'form:",omitempty"' }
+	Texts     struct{ A []Text }
+	Nested    struct{ A struct{ B Validated } }
+	Min       struct{ A int8 'min:"-200"' }
+	Regex     struct{ A string 'regex:"("' }
+	OneOf     struct{ A uint 'oneof:"1 -1"' }
+	Default   struct{ A []int 'default:"1,x"' }
+	Count     struct{ A []int 'maxcount:"1" default:"1,2"' }
+	Counts    struct{ A []Parsed 'maxcount:"1" default:"1,2"' }
+	Inner     struct{ A Valid 'default:"1"' }
+	NotStruct int
+)
+`, "'", "`")
+
+	if err := os.WriteFile(filepath.Join(dir, "types.go"), []byte(src), 0o644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	pkg, err := load(dir, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for n, test := range [...]struct {
+		Type string
+		Err  error
+	}{
+		{"Missing", errNotFound},
+		{"NotStruct", errNotStruct},
+		{"Validated", errValidator},
+		{"Chan", errUnsupported},
+		{"Map", errUnsupported},
+		{"Time", errTime},
+		{"Duration", errTime},
+		{"Structs", errUnsupported},
+		{"Pointers", errPointer},
+		{"Parser", errParser},
+		{"Marshaler", errMarshal},
+		{"OmitEmpty", errUnsupported},
+		{"Texts", errText},
+		{"Nested", errValidator},
+		{"Min", strconv.ErrRange},
+		{"Regex", nil},
+		{"OneOf", strconv.ErrSyntax},
+		{"Default", strconv.ErrSyntax},
+		{"Count", nil},
+		{"Counts", nil},
+		{"Inner", errUnsupported},
+	} {
+		_, err := generate(pkg, []string{test.Type})
+
+		var te *tagError
+
+		if err == nil {
+			t.Errorf("test %d: expecting error", n+1)
+		} else if test.Err == nil && !errors.As(err, &te) {
+			t.Errorf("test %d: expecting tag error, got %v", n+1, err)
+		} else if test.Err != nil && !errors.Is(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		}
+	}
+
+	if _, err := generate(pkg, []string{"Valid", "Parsers"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"errors"
+	"go/types"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"vimagination.zapto.org/form"
+)
+
+// spec holds the checks for a field, as set by its tags, and the literals used
+// to generate them.
+type spec struct {
+	result             types.Type
+	parser, bits       string
+	min, max           string
+	minLen, maxLen     int
+	regex              *regexp.Regexp
+	choices, values    []string
+	minCount, maxCount int
+	def                []string
+
+	parse  func(string) (interface{}, error)
+	parsed []interface{}
+}
+
+type tagError struct {
+	tag string
+	err error
+}
+
+func (t *tagError) Error() string {
+	return "invalid " + t.tag + " tag: " + t.err.Error()
+}
+
+func (t *tagError) Unwrap() error {
+	return t.err
+}
+
+func bitSize(b *types.Basic) (int, string) {
+	switch b.Kind() {
+	case types.Int8, types.Uint8:
+		return 8, "8"
+	case types.Int16, types.Uint16:
+		return 16, "16"
+	case types.Int32, types.Uint32, types.Float32:
+		return 32, "32"
+	case types.Int64, types.Uint64, types.Float64:
+		return 64, "64"
+	}
+
+	return strconv.IntSize, "strconv.IntSize"
+}
+
+func parseLimit(tags reflect.StructTag, tag string) (int, error) {
+	if l := tags.Get(tag); l != "" {
+		n, err := strconv.ParseUint(l, 10, 31)
+		if err != nil {
+			return 0, &tagError{tag: tag, err: err}
+		}
+
+		return int(n), nil
+	}
+
+	return 0, nil
+}
+
+func newSpec(b *types.Basic, tags reflect.StructTag, slice bool) (*spec, error) {
+	var (
+		s         = &spec{}
+		bits, bs  = bitSize(b)
+		min, max  = tags.Get("min"), tags.Get("max")
+		err       error
+		format    func(interface{}) string
+		hasChoice = true
+	)
+
+	s.bits = bs
+
+	switch info := b.Info(); {
+	case info&types.IsFloat != 0:
+		fmin, fmax := -math.MaxFloat64, math.MaxFloat64
+		s.min, s.max = "-math.MaxFloat64", "math.MaxFloat64"
+
+		if min != "" {
+			if fmin, err = strconv.ParseFloat(min, bits); err != nil {
+				return nil, &tagError{tag: "min", err: err}
+			}
+
+			s.min = strconv.FormatFloat(fmin, 'g', -1, 64)
+		}
+
+		if max != "" {
+			if fmax, err = strconv.ParseFloat(max, bits); err != nil {
+				return nil, &tagError{tag: "max", err: err}
+			}
+
+			s.max = strconv.FormatFloat(fmax, 'g', -1, 64)
+		}
+
+		s.result = types.Typ[types.Float64]
+		s.parser = "ParseFloat"
+		s.parse = func(data string) (interface{}, error) {
+			return form.ParseFloat(data, bits, fmin, fmax)
+		}
+		format = func(v interface{}) string {
+			return strconv.FormatFloat(v.(float64), 'g', -1, 64)
+		}
+	case info&types.IsUnsigned != 0:
+		umin, umax := uint64(0), uint64(math.MaxUint64)
+		s.min, s.max = "0", "math.MaxUint64"
+
+		if min != "" {
+			if umin, err = strconv.ParseUint(min, 10, bits); err != nil {
+				return nil, &tagError{tag: "min", err: err}
+			}
+
+			s.min = strconv.FormatUint(umin, 10)
+		}
+
+		if max != "" {
+			if umax, err = strconv.ParseUint(max, 10, bits); err != nil {
+				return nil, &tagError{tag: "max", err: err}
+			}
+
+			s.max = strconv.FormatUint(umax, 10)
+		}
+
+		s.result = types.Typ[types.Uint64]
+		s.parser = "ParseUint"
+		s.parse = func(data string) (interface{}, error) {
+			return form.ParseUint(data, bits, umin, umax)
+		}
+		format = func(v interface{}) string {
+			return strconv.FormatUint(v.(uint64), 10)
+		}
+	case info&types.IsInteger != 0:
+		imin, imax := int64(math.MinInt64), int64(math.MaxInt64)
+		s.min, s.max = "math.MinInt64", "math.MaxInt64"
+
+		if min != "" {
+			if imin, err = strconv.ParseInt(min, 10, bits); err != nil {
+				return nil, &tagError{tag: "min", err: err}
+			}
+
+			s.min = strconv.FormatInt(imin, 10)
+		}
+
+		if max != "" {
+			if imax, err = strconv.ParseInt(max, 10, bits); err != nil {
+				return nil, &tagError{tag: "max", err: err}
+			}
+
+			s.max = strconv.FormatInt(imax, 10)
+		}
+
+		s.result = types.Typ[types.Int64]
+		s.parser = "ParseInt"
+		s.parse = func(data string) (interface{}, error) {
+			return form.ParseInt(data, bits, imin, imax)
+		}
+		format = func(v interface{}) string {
+			return strconv.FormatInt(v.(int64), 10)
+		}
+	case info&types.IsString != 0:
+		if r := tags.Get("regex"); r != "" {
+			if s.regex, err = regexp.Compile(r); err != nil {
+				return nil, &tagError{tag: "regex", err: err}
+			}
+		}
+
+		if s.minLen, err = parseLimit(tags, "minlen"); err != nil {
+			return nil, err
+		} else if s.maxLen, err = parseLimit(tags, "maxlen"); err != nil {
+			return nil, err
+		}
+
+		s.result = types.Typ[types.String]
+		s.parse = func(data string) (interface{}, error) {
+			return data, form.CheckString(data, s.minLen, s.maxLen, s.regex)
+		}
+		format = func(v interface{}) string {
+			return strconv.Quote(v.(string))
+		}
+	default:
+		s.result = types.Typ[types.Bool]
+		s.parser = "ParseBool"
+		s.parse = func(data string) (interface{}, error) {
+			return form.ParseBool(data)
+		}
+		hasChoice = false
+	}
+
+	if hasChoice {
+		if err := s.setChoices(strings.Fields(tags.Get("oneof")), format); err != nil {
+			return nil, err
+		}
+	}
+
+	if slice {
+		if s.minCount, err = parseLimit(tags, "mincount"); err != nil {
+			return nil, err
+		} else if s.maxCount, err = parseLimit(tags, "maxcount"); err != nil {
+			return nil, err
+		}
+	}
+
+	if dv, ok := tags.Lookup("default"); ok {
+		if err := s.setDefault(dv, slice); err != nil {
+			return nil, &tagError{tag: "default", err: err}
+		}
+	}
+
+	return s, nil
+}
+
+// newParserSpec returns the spec for a type with a ParseForm method, which
+// performs its own checks, leaving only the count and default tags to be set.
+func newParserSpec(tags reflect.StructTag, slice, split bool) (*spec, error) {
+	var (
+		s   = &spec{}
+		err error
+	)
+
+	if slice {
+		if s.minCount, err = parseLimit(tags, "mincount"); err != nil {
+			return nil, err
+		} else if s.maxCount, err = parseLimit(tags, "maxcount"); err != nil {
+			return nil, err
+		}
+	}
+
+	if dv, ok := tags.Lookup("default"); !ok {
+		return s, nil
+	} else if !split {
+		s.def = []string{dv}
+	} else {
+		s.def = strings.Split(dv, ",")
+	}
+
+	if slice {
+		if err := form.CheckCount(len(s.def), s.minCount, s.maxCount); err != nil {
+			return nil, &tagError{tag: "default", err: err}
+		}
+	}
+
+	return s, nil
+}
+
+func (s *spec) setChoices(choices []string, format func(interface{}) string) error {
+	if len(choices) == 0 {
+		return nil
+	}
+
+	var errs []error
+
+	for _, c := range choices {
+		v, err := s.parse(c)
+		if err != nil {
+			errs = append(errs, &tagError{tag: "oneof", err: err})
+
+			continue
+		}
+
+		s.parsed = append(s.parsed, v)
+
+		if f, ok := v.(float64); !ok || !math.IsNaN(f) {
+			s.values = append(s.values, format(v))
+		}
+	}
+
+	s.choices = choices
+
+	return errors.Join(errs...)
+}
+
+func (s *spec) setDefault(dv string, slice bool) error {
+	if !slice {
+		s.def = []string{dv}
+
+		return s.check(dv)
+	}
+
+	s.def = strings.Split(dv, ",")
+
+	if err := form.CheckCount(len(s.def), s.minCount, s.maxCount); err != nil {
+		return err
+	}
+
+	var errs form.Errors
+
+	for n, d := range s.def {
+		if err := s.check(d); err != nil {
+			if errs == nil {
+				errs = make(form.Errors, len(s.def))
+			}
+
+			errs[n] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (s *spec) check(data string) error {
+	v, err := s.parse(data)
+	if err != nil || s.choices == nil {
+		return err
+	}
+
+	for _, c := range s.parsed {
+		if c == v {
+			return nil
+		}
+	}
+
+	return &form.ChoiceError{
+		Value:   data,
+		Choices: s.choices,
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+func (g *generator) use(path string) {
+	g.imports[path] = lastElem(path)
+}
+
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+
+	g.imports[p.Path()] = p.Name()
+
+	return p.Name()
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) literal(lit string) string {
+	if strings.Contains(lit, "math.") {
+		g.use("math")
+	} else if strings.Contains(lit, "strconv.") {
+		g.use("strconv")
+	}
+
+	return lit
+}
+
+func (g *generator) convert(t types.Type, expr string, to types.Type) string {
+	if types.Identical(t, to) {
+		return expr
+	}
+
+	return types.TypeString(to, g.qualifier) + "(" + expr + ")"
+}
+
+func (g *generator) regexVar(s *spec) string {
+	if s.regex == nil {
+		return "nil"
+	}
+
+	pattern := s.regex.String()
+
+	name, ok := g.regexps[pattern]
+	if !ok {
+		name = "regex" + g.typ + strconv.Itoa(len(g.regexps))
+		g.regexps[pattern] = name
+	}
+
+	return name
+}
+
+func (g *generator) writeRegexps() {
+	if len(g.regexps) == 0 {
+		return
+	}
+
+	g.use("regexp")
+
+	names := make([]string, len(g.regexps))
+
+	for pattern, name := range g.regexps {
+		n, _ := strconv.Atoi(strings.TrimPrefix(name, "regex"+g.typ))
+		names[n] = fmt.Sprintf("%s = regexp.MustCompile(%q)\n", name, pattern)
+	}
+
+	g.printf("\nvar (\n%s)\n", strings.Join(names, ""))
+}
+
+func (g *generator) writeParse(fm *fieldMap) {
+	g.printf("\n// ParseFormRequest parses the form data from the request into the %s, in the\n// same way as form.Process.\n", g.typ)
+	g.printf("func (s *%s) ParseFormRequest(r *http.Request) error {\n", g.typ)
+	g.printf("if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {\nreturn err\n}\n\n")
+
+	needQuery := false
+
+	for _, key := range fm.keys {
+		if fm.fields[key].src == sourceQuery {
+			needQuery = true
+		}
+	}
+
+	g.printf("var (\nerrs form.ErrorMap\n")
+
+	if needQuery {
+		g.printf("query url.Values\n")
+	}
+
+	if len(fm.keys) > 0 {
+		g.printf("val []string\nok bool\n")
+	}
+
+	g.printf(")\n\n")
+
+	if needQuery {
+		g.printf("if r.URL != nil {\nquery = r.URL.Query()\n}\n\n")
+	}
+
+	for _, key := range fm.keys {
+		g.writeParseField(fm.fields[key])
+	}
+
+	g.printf("if len(errs) > 0 {\nreturn errs\n}\n\nreturn nil\n}\n")
+}
+
+func (g *generator) writeParseField(f field) {
+	key := strconv.Quote(f.key)
+
+	switch f.src {
+	case sourceQuery:
+		g.printf("val, ok = query[%s]\n", key)
+	case sourceHeader:
+		g.printf("val = r.Header.Values(%s)\nok = len(val) > 0\n", key)
+	case sourceCookie:
+		g.printf("val = nil\n\nfor _, c := range r.Cookies() {\nif c.Name == %s {\nval = append(val, c.Value)\n}\n}\n\nok = len(val) > 0\n", key)
+	case sourcePath:
+		g.printf("if pv := r.PathValue(%s); pv != \"\" {\nval, ok = []string{pv}, true\n} else {\nval, ok = nil, false\n}\n", key)
+	default:
+		if f.post {
+			g.printf("val, ok = r.PostForm[%s]\n", key)
+		} else {
+			g.printf("val, ok = r.Form[%s]\n", key)
+		}
+	}
+
+	if f.spec.def != nil {
+		g.printf("\nif !ok {\nval, ok = %s, true\n}\n", quoteStrings(f.spec.def))
+	}
+
+	g.printf("\nif ok {\n")
+
+	onErr := "errs = form.AddError(errs, " + key + ", err)"
+
+	if f.slice {
+		g.writeParseSlice(f, key, onErr)
+	} else if f.parser {
+		g.printf("if err := s.%s.ParseForm(val); err != nil {\n%s\n}\n", f.path, onErr)
+	} else {
+		g.writeValue(f, "val[0]", "s."+f.path, onErr)
+	}
+
+	if f.required && f.spec.def == nil {
+		g.printf("} else {\nerrs = form.AddError(errs, %s, form.ErrRequiredMissing)\n", key)
+	}
+
+	g.printf("}\n\n")
+}
+
+func (g *generator) writeParseSlice(f field, key, onErr string) {
+	field := "s." + f.path
+	counted := f.spec.minCount > 0 || f.spec.maxCount > 0
+
+	if counted {
+		g.printf("if err := form.CheckCount(len(val), %d, %d); err != nil {\n%s\n} else {\n", f.spec.minCount, f.spec.maxCount, onErr)
+	}
+
+	elem := types.TypeString(f.typ, g.qualifier)
+
+	if f.ptr {
+		elem = "*" + elem
+	}
+
+	g.printf("if cap(%s) >= len(val) {\n%s = %s[:len(val)]\n} else {\n%s = make([]%s, len(val))\n}\n\n", field, field, field, field, elem)
+	onElemErr := "if es == nil {\nes = make(form.Errors, len(val))\n}\n\nes[n] = err"
+
+	if f.parser {
+		g.printf("var es form.Errors\n\nfor n := range val {\nif err := %s[n].ParseForm(val[n:]); err != nil {\n%s\n}\n", field, onElemErr)
+	} else {
+		g.printf("var es form.Errors\n\nfor n, v := range val {\n")
+		g.writeValue(f, "v", field+"[n]", onElemErr)
+	}
+
+	g.printf("}\n\nif es != nil {\nerrs = form.AddError(errs, %s, es)\n}\n", key)
+
+	if counted {
+		g.printf("}\n")
+	}
+}
+
+func (g *generator) writeValue(f field, data, target, onErr string) {
+	var (
+		s       = f.spec
+		clauses []string
+		val     = "x"
+	)
+
+	switch s.parser {
+	case "":
+		val = data
+
+		if s.minLen > 0 || s.maxLen > 0 || s.regex != nil {
+			clauses = append(clauses, fmt.Sprintf("err := form.CheckString(%s, %d, %d, %s)", data, s.minLen, s.maxLen, g.regexVar(s)))
+		}
+	case "ParseBool":
+		clauses = append(clauses, fmt.Sprintf("x, err := form.ParseBool(%s)", data))
+	default:
+		clauses = append(clauses, fmt.Sprintf("x, err := form.%s(%s, %s, %s, %s)", s.parser, data, g.literal(s.bits), g.literal(s.min), g.literal(s.max)))
+	}
+
+	if s.choices != nil {
+		clauses = append(clauses, fmt.Sprintf("err := form.CheckChoice(%s, %s, %s%s)", val, data, quoteStrings(s.choices), prefixJoin(s.values)))
+	}
+
+	for n, clause := range clauses {
+		if n > 0 {
+			g.printf(" else ")
+		}
+
+		g.printf("if %s; err != nil {\n%s\n}", clause, onErr)
+	}
+
+	if len(clauses) > 0 {
+		g.printf(" else {\n")
+	}
+
+	v := g.convert(s.result, val, f.typ)
+
+	if f.ptr {
+		g.printf("p := %s\n%s = &p\n", v, target)
+	} else {
+		g.printf("%s = %s\n", target, v)
+	}
+
+	if len(clauses) > 0 {
+		g.printf("}\n")
+	}
+}
+
+func (g *generator) writeEncode(fm *fieldMap) {
+	g.printf("\n// EncodeForm encodes the %s into form values, in the same way as\n// form.Encode.\n", g.typ)
+	g.printf("func (s %s) EncodeForm() url.Values {\nvals := make(url.Values)\n\n", g.typ)
+
+	for _, key := range fm.keys {
+		if f := fm.fields[key]; f.src == sourceForm || f.src == sourceQuery {
+			g.writeEncodeField(f)
+		}
+	}
+
+	g.printf("return vals\n}\n")
+}
+
+func (g *generator) writeEncodeField(f field) {
+	var (
+		key   = strconv.Quote(f.key)
+		field = "s." + f.path
+	)
+
+	switch {
+	case f.parser:
+		g.writeEncodeParser(f, key, field)
+	case f.slice && f.ptr:
+		g.printf("if len(%s) > 0 {\nvar data []string\n\nfor _, e := range %s {\nif e != nil {\ndata = append(data, %s)\n}\n}\n\nif len(data) > 0 {\nvals[%s] = data\n}\n}\n\n", field, field, g.format(f, "*e"), key)
+	case f.slice:
+		g.printf("if len(%s) > 0 {\ndata := make([]string, len(%s))\n\nfor n, e := range %s {\ndata[n] = %s\n}\n\nvals[%s] = data\n}\n\n", field, field, field, g.format(f, "e"), key)
+	case f.ptr:
+		g.printf("if %s != nil {\nvals[%s] = []string{%s}\n}\n\n", field, key, g.format(f, "*"+field))
+	case f.omitEmpty:
+		g.printf("if %s {\nvals[%s] = []string{%s}\n}\n\n", g.notZero(f, field), key, g.format(f, field))
+	default:
+		g.printf("vals[%s] = []string{%s}\n\n", key, g.format(f, field))
+	}
+}
+
+// writeEncodeParser writes the encoding of a type with a ParseForm method,
+// which uses its FormatForm method when it has one, or is otherwise encoded by
+// its kind.
+func (g *generator) writeEncodeParser(f field, key, field string) {
+	_, ptr := f.typ.Underlying().(*types.Pointer)
+
+	if !implements(f.typ, formatIface) {
+		if b, ok := f.typ.Underlying().(*types.Basic); ok && isBasic(b) {
+			f.spec, _ = newSpec(b, "", false)
+			f.parser = false
+
+			g.writeEncodeField(f)
+		}
+
+		return
+	}
+
+	if f.slice {
+		g.printf("if len(%s) > 0 {\nvar data []string\n\nfor n := range %s {\n", field, field)
+
+		if ptr {
+			g.printf("if %s[n] != nil {\ndata = append(data, %s[n].FormatForm()...)\n}\n", field, field)
+		} else {
+			g.printf("data = append(data, %s[n].FormatForm()...)\n", field)
+		}
+
+		g.printf("}\n\nif len(data) > 0 {\nvals[%s] = data\n}\n}\n\n", key)
+
+		return
+	}
+
+	cond := ""
+
+	if ptr {
+		cond = field + " != nil"
+	} else if f.omitEmpty {
+		cond = g.notZeroValue(f.typ, field)
+	}
+
+	if cond != "" {
+		g.printf("if %s {\n", cond)
+	}
+
+	g.printf("if data := %s.FormatForm(); len(data) > 0 {\nvals[%s] = data\n}\n", field, key)
+
+	if cond != "" {
+		g.printf("}\n")
+	}
+
+	g.printf("\n")
+}
+
+func (g *generator) format(f field, expr string) string {
+	s := f.spec
+	v := g.convert(f.typ, expr, s.result)
+
+	switch s.parser {
+	case "":
+		return v
+	case "ParseBool":
+		g.use("strconv")
+
+		return "strconv.FormatBool(" + v + ")"
+	case "ParseFloat":
+		g.use("strconv")
+
+		return "strconv.FormatFloat(" + v + ", 'g', -1, " + s.bits + ")"
+	case "ParseUint":
+		g.use("strconv")
+
+		return "strconv.FormatUint(" + v + ", 10)"
+	}
+
+	g.use("strconv")
+
+	return "strconv.FormatInt(" + v + ", 10)"
+}
+
+func (g *generator) notZero(f field, expr string) string {
+	switch f.spec.parser {
+	case "":
+		return expr + ` != ""`
+	case "ParseBool":
+		return expr
+	case "ParseFloat":
+		g.use("math")
+
+		return "math.Float64bits(float64(" + expr + ")) != 0"
+	}
+
+	return expr + " != 0"
+}
+
+func (g *generator) notZeroValue(t types.Type, expr string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if !isBasic(u) {
+			return expr + " != 0"
+		}
+
+		s, _ := newSpec(u, "", false)
+
+		return g.notZero(field{spec: s}, expr)
+	case *types.Struct, *types.Array:
+		return expr + " != (" + types.TypeString(t, g.qualifier) + "{})"
+	}
+
+	return expr + " != nil"
+}
+
+func quoteStrings(strs []string) string {
+	quoted := make([]string, len(strs))
+
+	for n, s := range strs {
+		quoted[n] = strconv.Quote(s)
+	}
+
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+func prefixJoin(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+
+	return ", " + strings.Join(strs, ", ")
+}
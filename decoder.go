@@ -0,0 +1,584 @@
+package form
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ParserFunc is a function that parses form data into the given value.
+type ParserFunc func(v reflect.Value, data []string) error
+
+// FormatterFunc is a function that formats the given value into form data.
+type FormatterFunc func(v reflect.Value) ([]string, error)
+
+// Option is a function that sets an option on a Decoder.
+type Option func(*Decoder)
+
+// TagName sets the name of the struct tag used to determine the key names and
+// options of fields. The default is 'form'.
+func TagName(name string) Option {
+	return func(d *Decoder) {
+		d.tag = name
+	}
+}
+
+// NameFunc sets a function that converts field names into keys, for fields
+// that do not have a name set in their tag.
+func NameFunc(fn func(string) string) Option {
+	return func(d *Decoder) {
+		d.nameFunc = fn
+	}
+}
+
+// CaseInsensitive makes the matching of keys case insensitive. Keys in any
+// returned ErrorMap will be in lower case, except for those of fields with the
+// 'header', 'cookie' or 'path' sources, which keep the case of their names so
+// that path values can be found; headers and cookies are matched regardless
+// of case.
+func CaseInsensitive() Option {
+	return func(d *Decoder) {
+		d.caseInsensitive = true
+	}
+}
+
+// MaxIndex sets the default maximum index for slices of structs, which can be
+// overridden with the 'maxindex' tag. The default is 1000.
+func MaxIndex(n int) Option {
+	return func(d *Decoder) {
+		d.maxIndex = n
+	}
+}
+
+// MaxKeys sets the default maximum number of entries for maps, which can be
+// overridden with the 'maxkeys' tag. The default is 1000.
+func MaxKeys(n int) Option {
+	return func(d *Decoder) {
+		d.maxKeys = n
+	}
+}
+
+// MaxMemory sets the maximum number of bytes of a multipart request that will
+// be stored in memory, with the rest being stored on disk. The default is 32MB.
+func MaxMemory(n int64) Option {
+	return func(d *Decoder) {
+		d.maxMemory = n
+	}
+}
+
+// Booleans sets the case-insensitive words that are accepted as true and false
+// values for boolean fields. The first word of each list is used when
+// encoding.
+func Booleans(trues, falses []string) Option {
+	return func(d *Decoder) {
+		d.trues = toLowerBytes(trues)
+		d.falses = toLowerBytes(falses)
+	}
+}
+
+func toLowerBytes(words []string) [][]byte {
+	bs := make([][]byte, len(words))
+
+	for n, word := range words {
+		bs[n] = []byte(strings.ToLower(word))
+	}
+
+	return bs
+}
+
+// CustomType sets a parser, and optionally a formatter, for the given type,
+// which take precedence over all other ways of processing the type.
+//
+// If the formatter is nil, the value is encoded according to its kind.
+func CustomType(t reflect.Type, parser ParserFunc, formatter FormatterFunc) Option {
+	return func(d *Decoder) {
+		if d.types == nil {
+			d.types = make(map[reflect.Type]custom)
+		}
+
+		d.types[t] = custom{
+			parse:  parser,
+			format: formatter,
+		}
+	}
+}
+
+// Enum registers the given named constants, which must all be of the same
+// type, to be processed from, and encoded to, the labels returned by their
+// String methods. Any other label returns a *ChoiceError.
+//
+// As with CustomType, this takes precedence over all other ways of processing
+// the type.
+func Enum(values ...fmt.Stringer) Option {
+	if len(values) == 0 {
+		return func(*Decoder) {}
+	}
+
+	var (
+		labels = make([]string, len(values))
+		byName = make(map[string]reflect.Value, len(values))
+	)
+
+	for n, v := range values {
+		labels[n] = v.String()
+		byName[labels[n]] = reflect.ValueOf(v)
+	}
+
+	return CustomType(reflect.TypeOf(values[0]), func(v reflect.Value, data []string) error {
+		e, ok := byName[data[0]]
+		if !ok {
+			return &ChoiceError{
+				Value:   data[0],
+				Choices: labels,
+			}
+		}
+
+		v.Set(e)
+
+		return nil
+	}, func(v reflect.Value) ([]string, error) {
+		return []string{v.Interface().(fmt.Stringer).String()}, nil
+	})
+}
+
+// StopOnError makes processing stop after the first error is encountered.
+func StopOnError() Option {
+	return func(d *Decoder) {
+		d.stopOnError = true
+	}
+}
+
+// Strict makes keys that would not be processed into any field, including the
+// names of files uploaded in a multipart form, be reported in the returned
+// ErrorMap with an ErrUnknownKey error. Keys that are expected but not
+// processed, such as CSRF tokens or the names of submit buttons, can be allowed
+// by passing them to Strict.
+//
+// Allowed keys are also ignored by UnusedKeys, which can be used without
+// Strict to find unexpected keys for logging.
+func Strict(allowed ...string) Option {
+	return func(d *Decoder) {
+		d.strict = true
+
+		if d.allowedKeys == nil {
+			d.allowedKeys = make(map[string]struct{}, len(allowed))
+		}
+
+		for _, key := range allowed {
+			d.allowedKeys[key] = struct{}{}
+		}
+	}
+}
+
+// AlwaysValidate makes the ValidateForm methods of structs be called even when
+// there are errors from processing the fields.
+func AlwaysValidate() Option {
+	return func(d *Decoder) {
+		d.alwaysValidate = true
+	}
+}
+
+// Location sets the default location used when parsing times without a time
+// zone. The default is UTC.
+func Location(loc *time.Location) Option {
+	return func(d *Decoder) {
+		d.loc = loc
+	}
+}
+
+// Translator turns an error code, as returned by ErrorCode, into a message in
+// the given language, returning false if the language is not supported. An
+// empty language requests the default language of the Translator.
+//
+// The params contain the 'name' of the key, and, depending on the error, the
+// 'value' that was rejected, the 'min' and 'max' of a range, length or count,
+// the 'length' of a string, the 'count' of values, the 'pattern' of a regular
+// expression, the 'choices' that are allowed, and the parse 'error'.
+type Translator interface {
+	Translate(lang, code string, params map[string]interface{}) (string, bool)
+}
+
+// Translation sets the Translator used for the messages of a Problem.
+func Translation(t Translator) Option {
+	return func(d *Decoder) {
+		d.translator = t
+	}
+}
+
+// ConflictPolicy determines how a key that is set in both the query string and
+// the post data is handled.
+type ConflictPolicy uint8
+
+// Conflict policies.
+const (
+	// ConflictMerge uses the values from both, with post values first.
+	ConflictMerge ConflictPolicy = iota
+	// ConflictPreferPost uses only the post values.
+	ConflictPreferPost
+	// ConflictPreferQuery uses only the query values.
+	ConflictPreferQuery
+	// ConflictError reports an ErrConflict error for the key.
+	ConflictError
+)
+
+// Conflict sets how a key that is set in both the query string and the post
+// data is handled for fields without a 'post' or 'query' option. The default
+// is ConflictMerge.
+func Conflict(policy ConflictPolicy) Option {
+	return func(d *Decoder) {
+		d.conflict = policy
+	}
+}
+
+type formRequestParser interface {
+	ParseFormRequest(*http.Request) error
+}
+
+type formEncoder interface {
+	EncodeForm() url.Values
+}
+
+// Decoder processes form data into structs according to its options, keeping
+// its own cache of type information.
+type Decoder struct {
+	tag             string
+	nameFunc        func(string) string
+	caseInsensitive bool
+	maxIndex        int
+	maxKeys         int
+	maxMemory       int64
+	trues, falses   [][]byte
+	types           map[reflect.Type]custom
+	stopOnError     bool
+	conflict        ConflictPolicy
+	loc             *time.Location
+	translator      Translator
+	alwaysValidate  bool
+	strict          bool
+	allowedKeys     map[string]struct{}
+	generated       bool
+
+	cache sync.Map
+	mu    sync.Mutex
+	calls map[reflect.Type]*typeInfoCall
+}
+
+var defaultDecoder = NewDecoder()
+
+// NewDecoder creates a new Decoder with the given options.
+//
+// A Decoder created without options, like the default Decoder, uses the
+// ParseFormRequest and EncodeForm methods generated by the formgen command,
+// when they exist, in place of processing and encoding the type with
+// reflection. As the generated methods are built for the default options, they
+// are never used by a Decoder with options.
+//
+// Methods with those names are assumed to have been generated by formgen, so
+// should not be used for any other purpose. As formgen does not support
+// ValidateForm methods, a ParseFormRequest method is not used for a type that
+// has one, either on itself or on a nested or embedded struct, so a method
+// added after generation is still called.
+func NewDecoder(opts ...Option) *Decoder {
+	d := &Decoder{
+		tag:       "form",
+		maxIndex:  defaultMaxIndex,
+		maxKeys:   defaultMaxKeys,
+		maxMemory: defaultMaxMemory,
+		calls:     make(map[reflect.Type]*typeInfoCall),
+		generated: len(opts) == 0,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Evict removes the type information for the given struct types from the cache
+// of the Decoder, so that it can be freed. Programs that create many struct
+// types, for example with reflect.StructOf, can use this to stop the cache
+// growing without bound.
+//
+// Types containing an evicted type are not affected, and an evicted type is
+// added back to the cache if it is processed again. A Binder keeps the type
+// information of its type regardless of the cache.
+func (d *Decoder) Evict(types ...reflect.Type) {
+	for _, t := range types {
+		d.cache.Delete(t)
+	}
+}
+
+// ClearCache removes the type information for all types from the cache of the
+// Decoder.
+func (d *Decoder) ClearCache() {
+	d.cache.Range(func(t, _ interface{}) bool {
+		d.cache.Delete(t)
+
+		return true
+	})
+}
+
+// Evict removes the type information for the given struct types from the cache
+// of the default Decoder, see Decoder.Evict.
+func Evict(types ...reflect.Type) {
+	defaultDecoder.Evict(types...)
+}
+
+// ClearCache removes the type information for all types from the cache of the
+// default Decoder.
+func ClearCache() {
+	defaultDecoder.ClearCache()
+}
+
+// Process parses the form data from the request into the passed value, which
+// must be a pointer to a struct.
+//
+// See the package level Process function for details of the processing.
+func (d *Decoder) Process(r *http.Request, fv interface{}) error {
+	return d.ProcessIn(r, fv, nil)
+}
+
+// ProcessIn acts like Process, but uses the given location when parsing times
+// without a time zone, unless overridden with a 'tz' tag.
+func (d *Decoder) ProcessIn(r *http.Request, fv interface{}, loc *time.Location) error {
+	if p, ok := fv.(formRequestParser); ok && d.useGenerated(fv) {
+		return p.ParseFormRequest(r)
+	}
+
+	vals, err := d.requestValues(r, loc)
+	if err != nil {
+		return err
+	}
+
+	return d.process(fv, vals)
+}
+
+// useGenerated determines whether a ParseFormRequest method can be used in
+// place of reflection, which is not the case when the Decoder has options or
+// the type has ValidateForm methods, which formgen does not support.
+func (d *Decoder) useGenerated(fv interface{}) bool {
+	if !d.generated {
+		return false
+	}
+
+	v := reflect.ValueOf(fv)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+
+	ti, err := d.getTypeInfo(v.Elem().Type())
+
+	return err == nil && len(ti.validators) == 0
+}
+
+func (d *Decoder) requestValues(r *http.Request, loc *time.Location) (values, error) {
+	if err := d.parseForm(r); err != nil {
+		return values{}, err
+	}
+
+	vals := values{
+		form:  r.Form,
+		post:  r.PostForm,
+		split: true,
+		req:   r,
+		loc:   loc,
+	}
+
+	if r.MultipartForm != nil {
+		vals.files = r.MultipartForm.File
+	}
+
+	return vals, nil
+}
+
+// parseForm acts like ParseMultipartForm, ignoring ErrNotMultipart, but avoids
+// parsing the Content-Type header when it cannot be a multipart form.
+func (d *Decoder) parseForm(r *http.Request) error {
+	if ct := r.Header.Get("Content-Type"); ct == "" || r.Body != nil && !isMultipart(ct) {
+		return r.ParseForm()
+	}
+
+	if err := r.ParseMultipartForm(d.maxMemory); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+
+	return nil
+}
+
+func isMultipart(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+
+	return strings.EqualFold(strings.TrimSpace(mediaType), "multipart/form-data")
+}
+
+// ProcessValues parses the given values into the passed value, which must be
+// a pointer to a struct.
+//
+// As there is no distinction between query and post values, fields with the
+// 'post' or 'query' options are also parsed from the given values.
+func (d *Decoder) ProcessValues(vals url.Values, fv interface{}) error {
+	return d.process(fv, values{
+		form:  vals,
+		post:  vals,
+		query: vals,
+	})
+}
+
+// ProcessQueryPost parses the given query and post values into the passed
+// value, which must be a pointer to a struct.
+//
+// The values are combined as they would be for the Form field of an
+// http.Request, with post values preceding query values, and fields with the
+// 'post' option being parsed from only the post values.
+func (d *Decoder) ProcessQueryPost(query, post url.Values, fv interface{}) error {
+	form := make(url.Values, len(query)+len(post))
+
+	for key, val := range post {
+		form[key] = append(form[key], val...)
+	}
+
+	for key, val := range query {
+		form[key] = append(form[key], val...)
+	}
+
+	return d.process(fv, values{
+		form:  form,
+		post:  post,
+		query: query,
+		split: true,
+	})
+}
+
+func (d *Decoder) process(fv interface{}, vals values) error {
+	v := reflect.ValueOf(fv)
+	if v.Kind() != reflect.Ptr {
+		return ErrNeedPointer
+	}
+
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return ErrNeedStruct
+	}
+
+	ti, err := d.getTypeInfo(v.Type())
+	if err != nil {
+		return err
+	}
+
+	return d.processStruct(v, ti, vals)
+}
+
+func (d *Decoder) processStruct(v reflect.Value, ti *typeInfo, vals values) error {
+	vals.dec = d
+
+	if vals.req != nil && vals.req.URL != nil && (ti.usesQuery || d.conflict != ConflictMerge) {
+		vals.query = vals.req.URL.Query()
+	}
+
+	if vals.loc == nil {
+		vals.loc = d.loc
+	}
+
+	if d.caseInsensitive {
+		vals.form = foldValues(vals.form)
+		vals.post = foldValues(vals.post)
+		vals.query = foldValues(vals.query)
+		vals.files = foldFiles(vals.files)
+	}
+
+	errors := ti.process(v, vals)
+
+	if d.strict && (len(errors) == 0 || !d.stopOnError) {
+		for _, key := range d.unusedKeys(ti.typeMap, vals.form, vals.files) {
+			if errors == nil {
+				errors = make(ErrorMap)
+			}
+
+			errors[key] = ErrUnknownKey
+		}
+	}
+
+	if errors = ti.validate(v, errors, d.alwaysValidate); len(errors) > 0 {
+		return errors
+	}
+
+	return nil
+}
+
+func foldValues(vals url.Values) url.Values {
+	folded := make(url.Values, len(vals))
+
+	for key, val := range vals {
+		key = strings.ToLower(key)
+		folded[key] = append(folded[key], val...)
+	}
+
+	return folded
+}
+
+func foldFiles(files map[string][]*multipart.FileHeader) map[string][]*multipart.FileHeader {
+	if files == nil {
+		return nil
+	}
+
+	folded := make(map[string][]*multipart.FileHeader, len(files))
+
+	for key, fhs := range files {
+		key = strings.ToLower(key)
+		folded[key] = append(folded[key], fhs...)
+	}
+
+	return folded
+}
+
+// Encode converts the passed struct, or pointer to a struct, into url.Values,
+// using the same keys and types that Process would use to parse them.
+//
+// See the package level Encode function for details of the encoding.
+func (d *Decoder) Encode(fv interface{}) (url.Values, error) {
+	v := reflect.ValueOf(fv)
+
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, ErrNeedStruct
+	}
+
+	if d.generated {
+		if e, ok := v.Interface().(formEncoder); ok {
+			return e.EncodeForm(), nil
+		}
+	}
+
+	if !v.CanAddr() {
+		av := reflect.New(v.Type()).Elem()
+
+		av.Set(v)
+
+		v = av
+	}
+
+	vals := make(url.Values)
+
+	tm, err := d.getTypeMap(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tm.encode(d, v, "", vals); err != nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
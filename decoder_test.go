@@ -0,0 +1,649 @@
+package form
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newRequest(get, post url.Values) *http.Request {
+	return &http.Request{
+		Method: http.MethodPost,
+		URL: &url.URL{
+			RawQuery: get.Encode(),
+		},
+		Header: http.Header{
+			"Content-Type": []string{"application/x-www-form-urlencoded"},
+		},
+		Body: io.NopCloser(strings.NewReader(post.Encode())),
+	}
+}
+
+type Celsius float64
+
+type Status int
+
+const (
+	Draft Status = iota
+	Published
+	Archived
+)
+
+func (s Status) String() string {
+	switch s {
+	case Draft:
+		return "draft"
+	case Published:
+		return "published"
+	case Archived:
+		return "archived"
+	}
+
+	return ""
+}
+
+func TestDecoder(t *testing.T) {
+	errCustom := errors.New("custom error")
+
+	for n, test := range [...]struct {
+		Options []Option
+		Get     url.Values
+		Output  interface{}
+		Err     error
+	}{
+		{ // 1
+			Options: []Option{TagName("json")},
+			Get: url.Values{
+				"a": []string{"1"},
+				"B": []string{"2"},
+			},
+			Output: struct {
+				A int `json:"a"`
+				B int `form:"b"`
+			}{
+				A: 1,
+				B: 2,
+			},
+		},
+		{ // 2
+			Options: []Option{NameFunc(strings.ToLower)},
+			Get: url.Values{
+				"first": []string{"1"},
+				"X":     []string{"2"},
+			},
+			Output: struct {
+				First  int
+				Second int `form:"X"`
+			}{
+				First:  1,
+				Second: 2,
+			},
+		},
+		{ // 3
+			Options: []Option{CaseInsensitive()},
+			Get: url.Values{
+				"NAME":          []string{"Alice"},
+				"Items[0].NAME": []string{"Bob"},
+			},
+			Output: struct {
+				Name  string `form:"name"`
+				Items []Row  `form:"items"`
+				Age   int    `form:"Age,required"`
+			}{
+				Name: "Alice",
+				Items: []Row{
+					{Name: "Bob"},
+				},
+			},
+			Err: ErrorMap{
+				"age": ErrRequiredMissing,
+			},
+		},
+		{ // 4
+			Options: []Option{MaxIndex(1), MaxKeys(1)},
+			Get: url.Values{
+				"A[2].name": []string{"Bob"},
+				"B[a]":      []string{"1"},
+				"B[b]":      []string{"2"},
+			},
+			Output: struct {
+				A []Row
+				B map[string]int
+			}{},
+			Err: ErrorMap{
+				"A": ErrInvalidIndex,
+				"B": ErrTooManyKeys,
+			},
+		},
+		{ // 5
+			Options: []Option{Booleans([]string{"Ja"}, []string{"Nein"})},
+			Get: url.Values{
+				"A": []string{"ja"},
+				"B": []string{"NEIN"},
+				"C": []string{"true"},
+			},
+			Output: struct {
+				A, B, C bool
+			}{
+				A: true,
+			},
+			Err: ErrorMap{
+				"C": &ParseError{Key: "C", Value: "true", Err: ErrInvalidBoolean},
+			},
+		},
+		{ // 6
+			Options: []Option{CustomType(reflect.TypeOf(Celsius(0)), func(v reflect.Value, data []string) error {
+				if !strings.HasSuffix(data[0], "C") {
+					return errCustom
+				}
+
+				v.SetFloat(1)
+
+				return nil
+			}, nil)},
+			Get: url.Values{
+				"A": []string{"20C"},
+				"B": []string{"20F"},
+			},
+			Output: struct {
+				A, B Celsius
+			}{
+				A: 1,
+			},
+			Err: ErrorMap{
+				"B": errCustom,
+			},
+		},
+		{ // 7
+			Options: []Option{Location(time.FixedZone("TEST", 3600))},
+			Get: url.Values{
+				"A": []string{"2020-01-01T00:00"},
+			},
+			Output: struct {
+				A time.Time
+			}{
+				A: time.Date(2020, 1, 1, 0, 0, 0, 0, time.FixedZone("TEST", 3600)),
+			},
+		},
+		{ // 8
+			Options: []Option{Enum(Draft, Published, Archived)},
+			Get: url.Values{
+				"A": []string{"published"},
+				"B": []string{"archived", "draft"},
+				"C": []string{"1"},
+			},
+			Output: struct {
+				A Status
+				B []Status
+				C Status
+			}{
+				A: Published,
+				B: []Status{Archived, Draft},
+			},
+			Err: ErrorMap{
+				"C": &ChoiceError{
+					Value:   "1",
+					Choices: []string{"draft", "published", "archived"},
+				},
+			},
+		},
+	} {
+		output := reflect.New(reflect.TypeOf(test.Output))
+
+		err := NewDecoder(test.Options...).Process(newRequest(test.Get, url.Values{}), output.Interface())
+		if !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: expecting error: %v\ngot: %v", n+1, test.Err, err)
+		}
+
+		if o := output.Elem().Interface(); !reflect.DeepEqual(o, test.Output) {
+			t.Errorf("test %d: expecting output: %#v\ngot: %#v", n+1, test.Output, o)
+		}
+	}
+}
+
+func TestDecoderStopOnError(t *testing.T) {
+	var output struct {
+		A, B, C int
+	}
+
+	err := NewDecoder(StopOnError()).Process(newRequest(url.Values{
+		"A": []string{"a"},
+		"B": []string{"b"},
+		"C": []string{"c"},
+	}, url.Values{}), &output)
+	if em, ok := err.(ErrorMap); !ok || len(em) != 1 {
+		t.Errorf("expecting a single error, got %v", err)
+	}
+}
+
+func TestDecoderBooleans(t *testing.T) {
+	d := NewDecoder(Booleans([]string{"Sí", "opt_in", "@yes"}, []string{"NO", "opt-out"}))
+
+	for n, test := range [...]struct {
+		Input  string
+		Output bool
+		Err    bool
+	}{
+		{"sí", true, false},
+		{"SÍ", true, false},
+		{"opt_in", true, false},
+		{"OPT_IN", true, false},
+		{"@YES", true, false},
+		{"no", false, false},
+		{"Opt-Out", false, false},
+		{"opt_out", false, true},
+		{"opt?in", false, true},
+		{"`yes", false, true},
+		{"si", false, true},
+		{"true", false, true},
+	} {
+		var output struct {
+			A bool
+		}
+
+		if err := d.ProcessValues(url.Values{"A": []string{test.Input}}, &output); (err != nil) != test.Err {
+			t.Errorf("test %d: unexpected error state: %v", n+1, err)
+		} else if output.A != test.Output {
+			t.Errorf("test %d: expecting %v, got %v", n+1, test.Output, output.A)
+		}
+	}
+}
+
+func TestDecoderCaseInsensitiveSources(t *testing.T) {
+	type session struct {
+		ID string
+	}
+
+	type sources struct {
+		Form    string  `form:"Form"`
+		Query   string  `form:"Query,query"`
+		Post    string  `form:"Post,post"`
+		Header  string  `form:"X-Header,header"`
+		Cookie  string  `form:"SessionID,cookie"`
+		Path    string  `form:"userID,path"`
+		Session session `form:"Sess,cookie"`
+	}
+
+	r := newRequest(url.Values{
+		"FORM":  []string{"form"},
+		"query": []string{"query"},
+	}, url.Values{
+		"POST": []string{"post"},
+	})
+
+	r.Header.Set("x-header", "header")
+	r.AddCookie(&http.Cookie{Name: "SessionID", Value: "cookie"})
+	r.AddCookie(&http.Cookie{Name: "sess.ID", Value: "nested"})
+	r.SetPathValue("userID", "path")
+
+	var output sources
+
+	if err := NewDecoder(CaseInsensitive()).Process(r, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := sources{
+		Form:    "form",
+		Query:   "query",
+		Post:    "post",
+		Header:  "header",
+		Cookie:  "cookie",
+		Path:    "path",
+		Session: session{ID: "nested"},
+	}
+
+	if output != expected {
+		t.Errorf("expecting output %#v, got %#v", expected, output)
+	}
+}
+
+func TestDecoderConflict(t *testing.T) {
+	type conflict struct {
+		A []int
+		B int
+		C int `form:",post"`
+		D []Row
+		E map[string]string
+		F []Row `form:",post"`
+	}
+
+	get := url.Values{
+		"A":         []string{"1"},
+		"B":         []string{"2"},
+		"C":         []string{"3"},
+		"D[0].name": []string{"query"},
+		"E[x]":      []string{"query"},
+		"F[0].name": []string{"query"},
+	}
+	post := url.Values{
+		"A":         []string{"4"},
+		"C":         []string{"5"},
+		"D[0].name": []string{"post"},
+		"E[x]":      []string{"post"},
+		"F[0].name": []string{"post"},
+	}
+
+	for n, test := range [...]struct {
+		Policy ConflictPolicy
+		Output conflict
+		Err    error
+	}{
+		{ // 1
+			Policy: ConflictMerge,
+			Output: conflict{
+				A: []int{4, 1},
+				B: 2,
+				C: 5,
+				D: []Row{{Name: "post"}},
+				E: map[string]string{"x": "post"},
+				F: []Row{{Name: "post"}},
+			},
+		},
+		{ // 2
+			Policy: ConflictPreferPost,
+			Output: conflict{
+				A: []int{4},
+				B: 2,
+				C: 5,
+				D: []Row{{Name: "post"}},
+				E: map[string]string{"x": "post"},
+				F: []Row{{Name: "post"}},
+			},
+		},
+		{ // 3
+			Policy: ConflictPreferQuery,
+			Output: conflict{
+				A: []int{1},
+				B: 2,
+				C: 5,
+				D: []Row{{Name: "query"}},
+				E: map[string]string{"x": "query"},
+				F: []Row{{Name: "post"}},
+			},
+		},
+		{ // 4
+			Policy: ConflictError,
+			Output: conflict{
+				B: 2,
+				C: 5,
+				D: []Row{{}},
+				E: map[string]string{},
+				F: []Row{{Name: "post"}},
+			},
+			Err: ErrorMap{
+				"A": ErrConflict,
+				"D": Errors{
+					ErrorMap{
+						"name": ErrConflict,
+					},
+				},
+				"E": ErrorMap{
+					"x": ErrConflict,
+				},
+			},
+		},
+	} {
+		d := NewDecoder(Conflict(test.Policy))
+
+		for m, process := range [...]func(*conflict) error{
+			func(output *conflict) error {
+				return d.Process(newRequest(get, post), output)
+			},
+			func(output *conflict) error {
+				return d.ProcessQueryPost(get, post, output)
+			},
+		} {
+			var output conflict
+
+			if err := process(&output); !reflect.DeepEqual(err, test.Err) {
+				t.Errorf("test %d.%d: expecting error %v, got %v", n+1, m+1, test.Err, err)
+			} else if !reflect.DeepEqual(output, test.Output) {
+				t.Errorf("test %d.%d: expecting output %#v, got %#v", n+1, m+1, test.Output, output)
+			}
+		}
+	}
+
+	var output conflict
+
+	if err := NewDecoder(Conflict(ConflictError)).ProcessValues(get, &output); err != nil {
+		t.Errorf("unexpected error from ProcessValues: %s", err)
+	}
+}
+
+func TestDecoderCache(t *testing.T) {
+	type cached struct {
+		A int `form:"a" json:"b"`
+	}
+
+	var a, b cached
+
+	if err := Process(newRequest(url.Values{"a": []string{"1"}}, url.Values{}), &a); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := NewDecoder(TagName("json")).Process(newRequest(url.Values{"b": []string{"2"}}, url.Values{}), &b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if a.A != 1 || b.A != 2 {
+		t.Errorf("expecting values 1 and 2, got %d and %d", a.A, b.A)
+	}
+}
+
+type treeNode struct {
+	Name     string     `form:"name"`
+	Children []treeNode `form:"children"`
+}
+
+func cachedTypes(d *Decoder) int {
+	var n int
+
+	d.cache.Range(func(_, _ interface{}) bool {
+		n++
+
+		return true
+	})
+
+	return n
+}
+
+func TestDecoderConcurrent(t *testing.T) {
+	d := NewDecoder()
+	types := make([]reflect.Type, 50)
+
+	for n := range types {
+		types[n] = reflect.StructOf([]reflect.StructField{
+			{Name: "A", Type: reflect.TypeOf(0), Tag: `form:"a"`},
+			{Name: "Rows", Type: reflect.TypeOf([]Row{}), Tag: reflect.StructTag(`form:"rows` + strconv.Itoa(n) + `"`)},
+			{Name: "Tree", Type: reflect.TypeOf(treeNode{}), Tag: `form:"tree"`},
+		})
+	}
+
+	input := url.Values{
+		"a":                                 []string{"1"},
+		"tree.name":                         []string{"root"},
+		"tree.children[0].name":             []string{"child"},
+		"tree.children[0].children[1].name": []string{"grandchild"},
+	}
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for _, typ := range types {
+				v := reflect.New(typ)
+
+				if err := d.ProcessValues(input, v.Interface()); err != nil {
+					t.Errorf("unexpected error: %s", err)
+
+					return
+				}
+
+				tree := v.Elem().Field(2).Interface().(treeNode)
+
+				if v.Elem().Field(0).Int() != 1 || tree.Children[0].Children[1].Name != "grandchild" {
+					t.Errorf("unexpected output: %v", v.Elem().Interface())
+
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := cachedTypes(d); n != len(types)+2 {
+		t.Errorf("expecting %d cached types, got %d", len(types)+2, n)
+	}
+
+	d.Evict(types[:10]...)
+
+	if n := cachedTypes(d); n != len(types)-8 {
+		t.Errorf("expecting %d cached types, got %d", len(types)-8, n)
+	}
+
+	d.ClearCache()
+
+	if n := cachedTypes(d); n != 0 {
+		t.Errorf("expecting no cached types, got %d", n)
+	}
+
+	var node treeNode
+
+	if err := d.ProcessValues(url.Values{"children[0].name": []string{"child"}}, &node); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if len(node.Children) != 1 || node.Children[0].Name != "child" {
+		t.Errorf("unexpected output: %v", node)
+	}
+}
+
+func TestDecoderEncode(t *testing.T) {
+	d := NewDecoder(TagName("json"), Booleans([]string{"yes"}, []string{"no"}), CustomType(reflect.TypeOf(Celsius(0)), func(reflect.Value, []string) error {
+		return nil
+	}, func(v reflect.Value) ([]string, error) {
+		return []string{"C"}, nil
+	}), Enum(Draft, Published, Archived))
+
+	output, err := d.Encode(struct {
+		A bool    `json:"a"`
+		B bool    `json:"b"`
+		C Celsius `json:"c"`
+		D Status  `json:"d"`
+	}{
+		A: true,
+		D: Archived,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := url.Values{
+		"a": []string{"yes"},
+		"b": []string{"no"},
+		"c": []string{"C"},
+		"d": []string{"archived"},
+	}
+
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("expecting output %v, got %v", expected, output)
+	}
+}
+
+type generatedInput struct {
+	A int `form:"a"`
+}
+
+func (g *generatedInput) ParseFormRequest(*http.Request) error {
+	g.A = -1
+
+	return nil
+}
+
+func (generatedInput) EncodeForm() url.Values {
+	return url.Values{"generated": []string{"true"}}
+}
+
+type validatedInner struct {
+	B int `form:"b"`
+}
+
+func (v validatedInner) ValidateForm() error {
+	if v.B < 0 {
+		return ErrNotInRange
+	}
+
+	return nil
+}
+
+type generatedValidated struct {
+	A     int            `form:"a"`
+	Inner validatedInner `form:"inner"`
+}
+
+func (g *generatedValidated) ParseFormRequest(*http.Request) error {
+	g.A = -1
+
+	return nil
+}
+
+func TestDecoderGenerated(t *testing.T) {
+	for n, test := range [...]struct {
+		Decoder *Decoder
+		Output  int
+		Encoded url.Values
+	}{
+		{ // 1
+			Decoder: defaultDecoder,
+			Output:  -1,
+			Encoded: url.Values{"generated": []string{"true"}},
+		},
+		{ // 2
+			Decoder: NewDecoder(),
+			Output:  -1,
+			Encoded: url.Values{"generated": []string{"true"}},
+		},
+		{ // 3
+			Decoder: NewDecoder(TagName("form")),
+			Output:  1,
+			Encoded: url.Values{"a": []string{"1"}},
+		},
+	} {
+		var output generatedInput
+
+		if err := test.Decoder.Process(newRequest(url.Values{"a": []string{"1"}}, url.Values{}), &output); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if output.A != test.Output {
+			t.Errorf("test %d: expecting output %d, got %d", n+1, test.Output, output.A)
+		}
+
+		if encoded, err := test.Decoder.Encode(&output); err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(encoded, test.Encoded) {
+			t.Errorf("test %d: expecting encoded %v, got %v", n+1, test.Encoded, encoded)
+		}
+	}
+
+	if err := Process(newRequest(url.Values{}, url.Values{}), (*generatedInput)(nil)); err != ErrNeedStruct {
+		t.Errorf("expecting error %s, got %v", ErrNeedStruct, err)
+	}
+
+	var validated generatedValidated
+
+	err := Process(newRequest(url.Values{"a": []string{"1"}, "inner.b": []string{"-1"}}, url.Values{}), &validated)
+	if expected := (ErrorMap{"inner": ErrNotInRange}); !reflect.DeepEqual(err, expected) {
+		t.Errorf("expecting error %v, got %v", expected, err)
+	} else if validated.A != 1 {
+		t.Errorf("expecting reflective processing for validated type, got %d", validated.A)
+	}
+}
@@ -0,0 +1,106 @@
+package form
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+var (
+	formatterType     = reflect.TypeOf((*formFormatter)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type formFormatter interface {
+	FormatForm() []string
+}
+
+func marshal(v reflect.Value, t reflect.Type) ([]string, bool, error) {
+	if !v.Type().Implements(t) {
+		if !v.CanAddr() || !reflect.PtrTo(v.Type()).Implements(t) {
+			return nil, false, nil
+		}
+
+		v = v.Addr()
+	} else if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, true, nil
+	}
+
+	if t == formatterType {
+		return v.Interface().(formFormatter).FormatForm(), true, nil
+	}
+
+	data, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, true, err
+	}
+
+	return []string{string(data)}, true, nil
+}
+
+func encodeKind(v reflect.Value) []string {
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return []string{strconv.FormatInt(v.Int(), 10)}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return []string{strconv.FormatUint(v.Uint(), 10)}
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())}
+	case reflect.String:
+		return []string{v.String()}
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}
+	}
+
+	return nil
+}
+
+func (tm typeMap) encode(d *Decoder, v reflect.Value, prefix string, vals url.Values) error {
+	for key, pd := range tm {
+		if pd.Source != sourceForm && pd.Source != sourceQuery {
+			continue
+		}
+
+		f := v.FieldByIndex(pd.Index)
+
+		if pd.OmitEmpty && f.IsZero() {
+			continue
+		}
+
+		if pd.Keys != nil {
+			if err := pd.Keys.encodeKeys(d, f, prefix+key, vals); err != nil {
+				return err
+			}
+		} else if data, err := pd.processor.encode(d, f); err != nil {
+			return err
+		} else if len(data) > 0 {
+			vals[prefix+key] = data
+		}
+	}
+
+	return nil
+}
+
+// Encode converts the passed struct, or pointer to a struct, into url.Values,
+// using the same keys and types that Process would use to parse them.
+//
+// Values are formatted to be accepted by the processor of the field, with
+// types that have a custom data processor able to supply a method with the
+// following specification to set the encoded data:
+//
+// FormatForm() []string
+//
+// If no such method exists, but the type implements encoding.TextMarshaler,
+// the MarshalText method is used.
+//
+// Times are formatted in the location set by the 'tz' tag, or the location set
+// on the Decoder with the Location option, defaulting to UTC, matching the
+// location used to parse them.
+//
+// The 'omitempty' option can be added to the form tag to skip encoding zero
+// values. Nil pointers, empty slices and empty maps are always skipped, as are
+// uploaded files and fields with the 'header', 'cookie' or 'path' sources.
+func Encode(fv interface{}) (url.Values, error) {
+	return defaultDecoder.Encode(fv)
+}
@@ -0,0 +1,330 @@
+package form
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Formatted []string
+
+func (f *Formatted) ParseForm(data []string) error {
+	*f = strings.Split(data[0], "|")
+
+	return nil
+}
+
+func (f Formatted) FormatForm() []string {
+	return []string{strings.Join(f, "|")}
+}
+
+type BadText struct{}
+
+func (BadText) MarshalText() ([]byte, error) {
+	return nil, errors.New("bad text")
+}
+
+func (*BadText) UnmarshalText([]byte) error {
+	return nil
+}
+
+func TestEncode(t *testing.T) {
+	for n, test := range [...]struct {
+		Input  interface{}
+		Output url.Values
+		Err    error
+	}{
+		{ // 1
+			Input: struct {
+				A int
+				B string `form:"b"`
+				C bool
+				D float64
+				E uint8
+			}{
+				A: -1,
+				B: "Hello",
+				C: true,
+				D: 1.5,
+				E: 255,
+			},
+			Output: url.Values{
+				"A": []string{"-1"},
+				"b": []string{"Hello"},
+				"C": []string{"true"},
+				"D": []string{"1.5"},
+				"E": []string{"255"},
+			},
+		},
+		{ // 2
+			Input: &struct {
+				A int    `form:",omitempty"`
+				B string `form:",omitempty"`
+				C *int
+				D []int
+				E string `form:"-"`
+				f string
+			}{
+				E: "Hidden",
+				f: "hidden",
+			},
+			Output: url.Values{},
+		},
+		{ // 3
+			Input: struct {
+				Z
+				Items []Row             `form:"items"`
+				Prefs map[string]string `form:"prefs"`
+				Nums  []int
+				Ptr   *int
+			}{
+				Z: Z{
+					C: 10,
+					Y: Y{
+						X: X{
+							A: "123",
+						},
+						B: true,
+					},
+				},
+				Items: []Row{
+					{Name: "Alice", Qty: 1},
+					{Name: "Bob", Qty: 2},
+				},
+				Prefs: map[string]string{
+					"colour": "red",
+				},
+				Nums: []int{1, 2, 3},
+				Ptr:  intPtr(4),
+			},
+			Output: url.Values{
+				"A":             []string{"123"},
+				"B":             []string{"true"},
+				"C":             []string{"10"},
+				"items[0].name": []string{"Alice"},
+				"items[0].qty":  []string{"1"},
+				"items[1].name": []string{"Bob"},
+				"items[1].qty":  []string{"2"},
+				"prefs[colour]": []string{"red"},
+				"Nums":          []string{"1", "2", "3"},
+				"Ptr":           []string{"4"},
+			},
+		},
+		{ // 4
+			Input: struct {
+				A time.Time
+				B time.Time
+				C time.Time
+				D time.Time `layout:"2006-W01"`
+				E time.Time `layout:"02/01/2006"`
+				F time.Duration
+				G time.Duration `unit:"m"`
+			}{
+				A: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+				B: time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC),
+				C: time.Date(0, 1, 1, 13, 14, 0, 0, time.UTC),
+				D: time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC),
+				E: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+				F: time.Hour + 30*time.Minute,
+				G: 90 * time.Second,
+			},
+			Output: url.Values{
+				"A": []string{"2020-02-03"},
+				"B": []string{"2020-02-03T04:05:06"},
+				"C": []string{"13:14:00"},
+				"D": []string{"2021-W01"},
+				"E": []string{"03/02/2020"},
+				"F": []string{"1h30m0s"},
+				"G": []string{"1.5"},
+			},
+		},
+		{ // 5
+			Input: struct {
+				A Formatted
+				B net.IP
+				C Upper
+			}{
+				A: Formatted{"a", "b"},
+				B: net.IPv4(127, 0, 0, 1),
+				C: "HELLO",
+			},
+			Output: url.Values{
+				"A": []string{"a|b"},
+				"B": []string{"127.0.0.1"},
+				"C": []string{"HELLO"},
+			},
+		},
+		{ // 6
+			Input: struct {
+				A BadText
+			}{},
+			Err: errors.New("bad text"),
+		},
+		{ // 7
+			Input: 1,
+			Err:   ErrNeedStruct,
+		},
+		{ // 8
+			Input: struct {
+				A string `form:"a,query"`
+				B string `form:"X-B,header"`
+				C string `form:"sess,cookie"`
+				D string `form:"id,path"`
+				E Row    `form:"e,cookie"`
+				F string `form:"f,post"`
+			}{
+				A: "a",
+				B: "b",
+				C: "secret",
+				D: "1",
+				E: Row{Name: "name"},
+				F: "f",
+			},
+			Output: url.Values{
+				"a": []string{"a"},
+				"f": []string{"f"},
+			},
+		},
+	} {
+		output, err := Encode(test.Input)
+		if !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if !reflect.DeepEqual(output, test.Output) {
+			t.Errorf("test %d: expecting output %v, got %v", n+1, test.Output, output)
+		}
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	type roundTrip struct {
+		Z
+		Name     string            `form:"name"`
+		Age      uint              `form:"age"`
+		Ratio    float32           `form:"ratio"`
+		Items    []Row             `form:"items"`
+		Prefs    map[string]string `form:"prefs"`
+		Tags     []string          `form:"tags"`
+		Created  time.Time         `form:"created"`
+		Moment   time.Time         `form:"moment"`
+		Local    time.Time         `form:"local"`
+		Week     time.Time         `form:"week" layout:"2006-W01"`
+		Timeout  time.Duration     `form:"timeout" unit:"s"`
+		Addr     net.IP            `form:"addr"`
+		Custom   Formatted         `form:"custom"`
+		Optional *int              `form:"optional"`
+	}
+
+	input := roundTrip{
+		Z: Z{
+			C: 1,
+			Y: Y{
+				X: X{
+					A: "a",
+				},
+				B: true,
+			},
+		},
+		Name:  "Alice",
+		Age:   30,
+		Ratio: 0.25,
+		Items: []Row{
+			{Name: "One", Qty: 1},
+			{Name: "Two", Qty: 2},
+		},
+		Prefs: map[string]string{
+			"colour": "red",
+			"size":   "large",
+		},
+		Tags:     []string{"x", "y"},
+		Created:  time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+		Moment:   time.Date(2020, 2, 3, 4, 5, 6, 789, time.UTC),
+		Local:    time.Date(2024, 1, 2, 10, 0, 0, 0, time.FixedZone("EST", -5*3600)),
+		Week:     time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC),
+		Timeout:  1500 * time.Millisecond,
+		Addr:     net.ParseIP("::1"),
+		Custom:   Formatted{"p", "q"},
+		Optional: intPtr(7),
+	}
+
+	vals, err := Encode(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	r := http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{},
+		Header: http.Header{
+			"Content-Type": []string{"application/x-www-form-urlencoded"},
+		},
+		Body: io.NopCloser(strings.NewReader(vals.Encode())),
+	}
+
+	var output roundTrip
+
+	if err := Process(&r, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !output.Local.Equal(input.Local) {
+		t.Errorf("expecting time %s, got %s", input.Local, output.Local)
+	}
+
+	output.Local = input.Local
+
+	if !reflect.DeepEqual(input, output) {
+		t.Errorf("expecting output %#v, got %#v", input, output)
+	}
+}
+
+func TestEncodeLocation(t *testing.T) {
+	loc := time.FixedZone("TEST", -5*3600)
+
+	type times struct {
+		A time.Time   `form:"a"`
+		B time.Time   `form:"b"`
+		C []time.Time `form:"c"`
+		D time.Time   `form:"d" tz:"UTC"`
+	}
+
+	input := times{
+		A: time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+		B: time.Date(2024, 12, 31, 10, 30, 0, 0, time.UTC),
+		C: []time.Time{time.Date(2024, 1, 2, 3, 4, 5, 0, loc)},
+		D: time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+	}
+
+	d := NewDecoder(Location(loc))
+
+	vals, err := d.Encode(input)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := url.Values{
+		"a": []string{"2024-12-31"},
+		"b": []string{"2024-12-31T05:30:00"},
+		"c": []string{"2024-01-02T03:04:05"},
+		"d": []string{"2024-12-31T05:00:00"},
+	}
+
+	if !reflect.DeepEqual(vals, expected) {
+		t.Errorf("expecting values %v, got %v", expected, vals)
+	}
+
+	var output times
+
+	if err := d.ProcessValues(vals, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !output.A.Equal(input.A) || !output.B.Equal(input.B) || len(output.C) != 1 || !output.C[0].Equal(input.C[0]) || !output.D.Equal(input.D) {
+		t.Errorf("expecting times %v, got %v", input, output)
+	}
+}
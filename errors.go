@@ -2,22 +2,314 @@ package form
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Errors is a list of errors that occurred when processing a slice of processors.
 type Errors []error
 
-// Error implements the error interface.
-func (Errors) Error() string {
-	return "multiple error occurred"
+// Error implements the error interface, listing the indexes and messages of
+// the errors.
+func (e Errors) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("multiple error occurred")
+
+	sep := ": "
+
+	for n, err := range e {
+		if err != nil {
+			sb.WriteString(sep)
+			sb.WriteString("[" + strconv.Itoa(n) + "] ")
+			sb.WriteString(err.Error())
+
+			sep = "; "
+		}
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the non-nil errors, allowing them to be matched with
+// errors.Is and errors.As.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+
+	for _, err := range e {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
 }
 
 // ErrorMap is a map of all of the keys that experienced errors.
 type ErrorMap map[string]error
 
+// Error implements the error interface, listing the keys, in sorted order,
+// and messages of the errors.
+func (e ErrorMap) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("errors occurred during processing form data")
+
+	sep := ": "
+
+	for _, key := range e.Keys() {
+		sb.WriteString(sep)
+		sb.WriteString(key)
+		sb.WriteString(": ")
+		sb.WriteString(e[key].Error())
+
+		sep = "; "
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the errors, ordered by key, allowing them to be matched with
+// errors.Is and errors.As.
+func (e ErrorMap) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+
+	for _, key := range e.Keys() {
+		errs = append(errs, e[key])
+	}
+
+	return errs
+}
+
+// Keys returns the keys of the ErrorMap in sorted order.
+//
+// See ErrorKeys for listing the keys in the order of the fields of a struct.
+func (e ErrorMap) Keys() []string {
+	keys := make([]string, 0, len(e))
+
+	for key := range e {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ErrorKeys returns the keys of the ErrorMap in the declaration order of the
+// fields of fv, which must be a struct or a pointer to a struct, as processed
+// by the Decoder. Keys not belonging to any field, such as those set by
+// ValidateForm methods or the Strict option, follow in sorted order.
+func (d *Decoder) ErrorKeys(fv interface{}, errs ErrorMap) ([]string, error) {
+	t := reflect.TypeOf(fv)
+	if t == nil {
+		return nil, ErrNeedStruct
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, ErrNeedStruct
+	}
+
+	tm, err := d.getTypeMap(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return tm.errorKeys(errs), nil
+}
+
+// ErrorKeys returns the keys of the ErrorMap in the declaration order of the
+// fields of fv, using the default Decoder.
+func ErrorKeys(fv interface{}, errs ErrorMap) ([]string, error) {
+	return defaultDecoder.ErrorKeys(fv, errs)
+}
+
+func (tm typeMap) errorKeys(errs ErrorMap) []string {
+	keys := make([]string, 0, len(errs))
+
+	for _, key := range tm.orderedKeys() {
+		if _, ok := errs[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, key := range errs.Keys() {
+		if _, ok := tm[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// RangeError is returned when a value is outside of the range set by the
+// 'min' and 'max' tags. Min and Max are nil when that side of the range is
+// not limited.
+type RangeError struct {
+	Min, Max, Value interface{}
+}
+
 // Error implements the error interface.
-func (ErrorMap) Error() string {
-	return "errors occurred during processing form data"
+func (r *RangeError) Error() string {
+	switch {
+	case r.Min != nil && r.Max != nil:
+		return fmt.Sprintf("value %v not in valid range, must be between %v and %v", r.Value, r.Min, r.Max)
+	case r.Min != nil:
+		return fmt.Sprintf("value %v not in valid range, must be at least %v", r.Value, r.Min)
+	case r.Max != nil:
+		return fmt.Sprintf("value %v not in valid range, must be at most %v", r.Value, r.Max)
+	}
+
+	return fmt.Sprintf("value %v not in valid range", r.Value)
+}
+
+// Is allows a RangeError to match ErrNotInRange.
+func (r *RangeError) Is(err error) bool {
+	return err == ErrNotInRange
+}
+
+// PatternError is returned when a string does not match the regular expression
+// set by the 'regex' tag.
+type PatternError struct {
+	Pattern, Value string
+}
+
+// Error implements the error interface.
+func (p *PatternError) Error() string {
+	return "string " + strconv.Quote(p.Value) + " did not match regex " + strconv.Quote(p.Pattern)
+}
+
+// Is allows a PatternError to match ErrNoMatch.
+func (p *PatternError) Is(err error) bool {
+	return err == ErrNoMatch
+}
+
+// ParseError is returned when a value could not be parsed into the type of a
+// field. Err is the underlying error, such as a *strconv.NumError,
+// ErrInvalidBoolean or ErrInvalidTime.
+type ParseError struct {
+	Key, Value string
+	Err        error
+}
+
+// Error implements the error interface.
+func (p *ParseError) Error() string {
+	if p.Key == "" {
+		return "invalid value " + strconv.Quote(p.Value) + ": " + p.Err.Error()
+	}
+
+	return "invalid value " + strconv.Quote(p.Value) + " for key " + strconv.Quote(p.Key) + ": " + p.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (p *ParseError) Unwrap() error {
+	return p.Err
+}
+
+func walkParseErrors(err error, fn func(*ParseError)) {
+	switch err := err.(type) {
+	case *ParseError:
+		fn(err)
+	case Errors:
+		for _, e := range err {
+			walkParseErrors(e, fn)
+		}
+	case ErrorMap:
+		for _, e := range err {
+			walkParseErrors(e, fn)
+		}
+	}
+}
+
+// TagError is returned when a struct tag could not be used for a field.
+type TagError struct {
+	Type  reflect.Type
+	Field string
+	Tag   string
+	Err   error
+}
+
+// Error implements the error interface.
+func (t *TagError) Error() string {
+	return "invalid " + t.Tag + " tag on field " + t.Type.String() + "." + t.Field + ": " + t.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (t *TagError) Unwrap() error {
+	return t.Err
+}
+
+// ChoiceError is returned when a value is not one of the allowed choices.
+type ChoiceError struct {
+	Value   string
+	Choices []string
+}
+
+// Error implements the error interface.
+func (c *ChoiceError) Error() string {
+	return "invalid choice " + strconv.Quote(c.Value) + ", must be one of: " + strings.Join(c.Choices, ", ")
+}
+
+// Is allows a ChoiceError to match ErrInvalidChoice.
+func (c *ChoiceError) Is(err error) bool {
+	return err == ErrInvalidChoice
+}
+
+// LengthError is returned when the length of a string, in runes, is outside of
+// the limits set by the 'minlen' and 'maxlen' tags. A Max of zero means that
+// the length is not limited.
+type LengthError struct {
+	Min, Max, Len int
+}
+
+// Error implements the error interface.
+func (l *LengthError) Error() string {
+	if l.Len < l.Min {
+		return fmt.Sprintf("string too short, length %d, must be at least %d", l.Len, l.Min)
+	}
+
+	return fmt.Sprintf("string too long, length %d, must be at most %d", l.Len, l.Max)
+}
+
+// Is allows a LengthError to match ErrTooShort or ErrTooLong.
+func (l *LengthError) Is(err error) bool {
+	if l.Len < l.Min {
+		return err == ErrTooShort
+	}
+
+	return err == ErrTooLong
+}
+
+// CountError is returned when the number of values for a slice is outside of
+// the limits set by the 'mincount' and 'maxcount' tags. A Max of zero means
+// that the count is not limited.
+type CountError struct {
+	Min, Max, Count int
+}
+
+// Error implements the error interface.
+func (c *CountError) Error() string {
+	if c.Count < c.Min {
+		return fmt.Sprintf("too few values, got %d, must be at least %d", c.Count, c.Min)
+	}
+
+	return fmt.Sprintf("too many values, got %d, must be at most %d", c.Count, c.Max)
+}
+
+// Is allows a CountError to match ErrTooFew or ErrTooMany.
+func (c *CountError) Is(err error) bool {
+	if c.Count < c.Min {
+		return err == ErrTooFew
+	}
+
+	return err == ErrTooMany
 }
 
 // Errors.
@@ -26,6 +318,20 @@ var (
 	ErrNeedStruct      = errors.New("need struct type")
 	ErrNotInRange      = errors.New("value not in valid range")
 	ErrInvalidBoolean  = errors.New("invalid boolean")
+	ErrInvalidTime     = errors.New("invalid time")
 	ErrRequiredMissing = errors.New("required field is missing")
+	ErrConflict        = errors.New("key set in both query and post data")
+	ErrUnknownKey      = errors.New("unknown key")
 	ErrNoMatch         = errors.New("string did not match regex")
+	ErrInvalidChoice   = errors.New("invalid choice")
+	ErrTooShort        = errors.New("string too short")
+	ErrTooLong         = errors.New("string too long")
+	ErrTooFew          = errors.New("too few values")
+	ErrTooMany         = errors.New("too many values")
+	ErrInvalidIndex    = errors.New("index exceeds maximum")
+	ErrInvalidKey      = errors.New("map key did not match regex")
+	ErrTooManyKeys     = errors.New("too many map keys")
+	ErrFileTooLarge    = errors.New("file too large")
+	ErrTooManyFiles    = errors.New("too many files")
+	ErrInvalidType     = errors.New("invalid content type")
 )
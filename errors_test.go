@@ -0,0 +1,134 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	var output struct {
+		A int    `min:"1" max:"5"`
+		B string `regex:"^[a-z]+$"`
+		C []bool
+		D []Row          `form:"d"`
+		E map[string]int `form:"e"`
+		F string         `oneof:"x y"`
+		G string         `minlen:"2"`
+		H []int          `maxcount:"1"`
+	}
+
+	err := ProcessValues(url.Values{
+		"A":        []string{"9"},
+		"B":        []string{"ABC"},
+		"C":        []string{"true", "perhaps"},
+		"d[1].qty": []string{"many"},
+		"e[k]":     []string{"1.5"},
+		"F":        []string{"z"},
+		"G":        []string{"g"},
+		"H":        []string{"1", "2"},
+	}, &output)
+
+	for n, target := range [...]error{
+		ErrNotInRange,
+		ErrNoMatch,
+		ErrInvalidBoolean,
+		ErrRequiredMissing,
+		strconv.ErrSyntax,
+		ErrInvalidChoice,
+		ErrTooShort,
+		ErrTooMany,
+	} {
+		if !errors.Is(err, target) {
+			t.Errorf("test %d: expecting error to match %q", n+1, target)
+		}
+	}
+
+	for n, target := range [...]error{ErrTooLong, ErrTooFew} {
+		if errors.Is(err, target) {
+			t.Errorf("test %d: expecting error not to match %q", n+1, target)
+		}
+	}
+
+	var le *LengthError
+
+	if !errors.As(err, &le) {
+		t.Errorf("expecting LengthError")
+	} else if *le != (LengthError{Min: 2, Len: 1}) {
+		t.Errorf("unexpected LengthError: %#v", le)
+	}
+
+	var re *RangeError
+
+	if !errors.As(err, &re) {
+		t.Errorf("expecting RangeError")
+	} else if re.Min != int64(1) || re.Max != int64(5) || re.Value != int64(9) {
+		t.Errorf("unexpected RangeError: %#v", re)
+	}
+
+	keys := make(map[string]bool)
+
+	walkParseErrors(err, func(pe *ParseError) {
+		keys[pe.Key] = true
+	})
+
+	for _, key := range [...]string{"C", "d[1].qty", "e[k]"} {
+		if !keys[key] {
+			t.Errorf("expecting ParseError with key %q, got %v", key, keys)
+		}
+	}
+
+	if em, ok := err.(ErrorMap); !ok {
+		t.Fatalf("expecting ErrorMap, got %T", err)
+	} else if msg, expected := (ErrorMap{"A": em["A"], "B": em["B"]}).Error(), `errors occurred during processing form data: A: value 9 not in valid range, must be between 1 and 5; B: string "ABC" did not match regex "^[a-z]+$"`; msg != expected {
+		t.Errorf("expecting message %q, got %q", expected, msg)
+	} else if msg, expected := em["C"].Error(), `multiple error occurred: [1] invalid value "perhaps" for key "C": invalid boolean`; msg != expected {
+		t.Errorf("expecting message %q, got %q", expected, msg)
+	}
+}
+
+func TestErrorKeys(t *testing.T) {
+	var output struct {
+		Z    int
+		A    int
+		Rows []Row `form:"rows"`
+		M    struct {
+			Y int
+			B int
+		}
+	}
+
+	err := NewDecoder(Strict()).ProcessValues(url.Values{
+		"Z":           []string{"x"},
+		"A":           []string{"x"},
+		"M.Y":         []string{"x"},
+		"M.B":         []string{"x"},
+		"rows[0].qty": []string{"x"},
+		"unknown":     []string{"x"},
+		"extra":       []string{"x"},
+	}, &output)
+
+	em, ok := err.(ErrorMap)
+	if !ok {
+		t.Fatalf("expecting ErrorMap, got %v", err)
+	}
+
+	if keys, expected := em.Keys(), []string{"A", "M.B", "M.Y", "Z", "extra", "rows", "unknown"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expecting sorted keys %v, got %v", expected, keys)
+	}
+
+	keys, err := ErrorKeys(&output, em)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := []string{"Z", "A", "rows", "M.Y", "M.B", "extra", "unknown"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expecting field ordered keys %v, got %v", expected, keys)
+	}
+
+	if _, err := ErrorKeys(1, em); !errors.Is(err, ErrNeedStruct) {
+		t.Errorf("expecting error ErrNeedStruct, got %v", err)
+	}
+}
@@ -3,64 +3,271 @@
 package form // import "vimagination.zapto.org/form"
 
 import (
+	"encoding"
+	"errors"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strings"
-	"sync"
+	"time"
 )
 
-var interType = reflect.TypeOf((*formParser)(nil)).Elem()
+var (
+	interType     = reflect.TypeOf((*formParser)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	fileType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileSliceType = reflect.SliceOf(fileType)
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+)
+
+const defaultMaxMemory = 32 << 20
+
+type source uint8
+
+const (
+	sourceForm source = iota
+	sourceQuery
+	sourceHeader
+	sourceCookie
+	sourcePath
+)
 
 type processorDetails struct {
 	processor
-	Post, Required bool
-	Index          []int
+	Keys                      keysProcessor
+	Post, Required, OmitEmpty bool
+	Source                    source
+	Index                     []int
+	Default                   []string
+	Message                   string
 }
 
 type typeMap map[string]processorDetails
 
-var (
-	tmMu     sync.RWMutex
-	typeMaps = make(map[reflect.Type]typeMap)
-)
+func (tm typeMap) orderedKeys() []string {
+	keys := make([]string, 0, len(tm))
+
+	for key := range tm {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := tm[keys[i]].Index, tm[keys[j]].Index
+
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+
+		return len(a) < len(b)
+	})
+
+	return keys
+}
+
+type field struct {
+	Key string
+	processorDetails
+}
 
-func getTypeMap(t reflect.Type) typeMap {
-	tmMu.RLock()
-	defer tmMu.RUnlock()
+type typeInfo struct {
+	typeMap
+	fields     []field
+	usesQuery  bool
+	validators []validator
+	tagErr     error
+}
 
-	tm, ok := typeMaps[t]
+type typeInfoCall struct {
+	done chan struct{}
+	ti   *typeInfo
+	err  error
+}
 
-	if !ok {
-		tm = createTypeMap(t)
+func (d *Decoder) getTypeInfo(t reflect.Type) (*typeInfo, error) {
+	if ti, ok := d.cache.Load(t); ok {
+		return ti.(*typeInfo), nil
 	}
 
-	return tm
+	d.mu.Lock()
+
+	if ti, ok := d.cache.Load(t); ok {
+		d.mu.Unlock()
+
+		return ti.(*typeInfo), nil
+	} else if c, ok := d.calls[t]; ok {
+		d.mu.Unlock()
+		<-c.done
+
+		return c.ti, c.err
+	}
+
+	c := &typeInfoCall{done: make(chan struct{})}
+	d.calls[t] = c
+
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.calls, t)
+		d.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.ti, c.err = d.buildTypeInfo(t)
+
+	return c.ti, c.err
 }
 
-func basicTypeProcessor(t reflect.Type, tag reflect.StructTag) processor {
+func (d *Decoder) buildTypeInfo(t reflect.Type) (*typeInfo, error) {
+	built := make(map[reflect.Type]*typeInfo)
+
+	ti, err := d.createTypeInfo(t, built)
+	if err != nil {
+		return nil, err
+	}
+
+	for bt, bti := range built {
+		if bt != t {
+			d.cache.LoadOrStore(bt, bti)
+		}
+	}
+
+	cti, _ := d.cache.LoadOrStore(t, ti)
+
+	return cti.(*typeInfo), nil
+}
+
+func (d *Decoder) getTypeMap(t reflect.Type) (typeMap, error) {
+	ti, err := d.getTypeInfo(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return ti.typeMap, nil
+}
+
+func (d *Decoder) basicTypeProcessor(t reflect.Type, tag reflect.StructTag) (processor, error) {
+	if t == timeType {
+		return newTimestamp(tag)
+	} else if t == durationType {
+		return newDuration(tag)
+	}
+
 	switch t.Kind() {
 	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		return newInum(tag, t.Bits())
+		i, err := newInum(tag, t.Bits())
+
+		return newOneOf[int64](tag, i, err)
 	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		return newUnum(tag, t.Bits())
+		u, err := newUnum(tag, t.Bits())
+
+		return newOneOf[uint64](tag, u, err)
 	case reflect.Float32, reflect.Float64:
-		return newFloat(tag, t.Bits())
+		f, err := newFloat(tag, t.Bits())
+
+		return newOneOf[float64](tag, f, err)
 	case reflect.String:
-		return newString(tag)
+		s, err := newString(tag)
+
+		return newOneOf[string](tag, s, err)
 	case reflect.Bool:
-		return boolean{}
+		return boolean{
+			trues:  d.trues,
+			falses: d.falses,
+		}, nil
 	}
 
-	return nil
+	return nil, nil
+}
+
+func (d *Decoder) valueProcessor(t reflect.Type, tag reflect.StructTag) (processor, error) {
+	if c, ok := d.types[t]; ok {
+		return c, nil
+	} else if t.Implements(interType) {
+		return inter(false), nil
+	} else if reflect.PtrTo(t).Implements(interType) {
+		return inter(true), nil
+	} else if t == timeType || t == durationType {
+		return d.basicTypeProcessor(t, tag)
+	} else if t.Kind() == reflect.Ptr {
+		if s, err := d.valueProcessor(t.Elem(), tag); s != nil {
+			return pointer{
+				processor: s,
+				typ:       t.Elem(),
+			}, err
+		}
+
+		return nil, nil
+	} else if t.Implements(textType) {
+		return text(false), nil
+	} else if reflect.PtrTo(t).Implements(textType) {
+		return text(true), nil
+	}
+
+	return d.basicTypeProcessor(t, tag)
+}
+
+func hasOption(options, option string) bool {
+	return strings.Contains(options+",", ","+option+",")
+}
+
+func (d *Decoder) createTypeMap(t reflect.Type) (typeMap, error) {
+	ti, err := d.createTypeInfo(t, make(map[reflect.Type]*typeInfo))
+	if err != nil {
+		return nil, err
+	}
+
+	return ti.typeMap, nil
 }
 
-func createTypeMap(t reflect.Type) typeMap {
-	tm, ok := typeMaps[t]
-	if ok {
-		return tm
+func (d *Decoder) createTypeInfo(t reflect.Type, built map[reflect.Type]*typeInfo) (*typeInfo, error) {
+	if ti, ok := d.cache.Load(t); ok {
+		return ti.(*typeInfo), nil
+	} else if ti, ok := built[t]; ok {
+		return ti, nil
+	}
+
+	ti := &typeInfo{
+		typeMap: make(typeMap),
+	}
+	built[t] = ti
+
+	if err := d.fillTypeInfo(t, ti, built); err != nil {
+		delete(built, t)
+
+		return nil, err
 	}
 
-	tm = make(typeMap)
+	return ti, nil
+}
+
+func setTagField(err error, t reflect.Type, field string) error {
+	switch e := err.(type) {
+	case *TagError:
+		if e.Type == nil {
+			e.Type = t
+			e.Field = field
+		}
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			setTagField(err, t, field)
+		}
+	}
+
+	return err
+}
+
+func (d *Decoder) fillTypeInfo(t reflect.Type, ti *typeInfo, built map[reflect.Type]*typeInfo) error {
+	var (
+		tm      = ti.typeMap
+		vs      []validator
+		tagErrs []error
+	)
 
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
@@ -70,79 +277,191 @@ func createTypeMap(t reflect.Type) typeMap {
 
 		name := f.Name
 
-		var required, post bool
+		if d.nameFunc != nil {
+			name = d.nameFunc(name)
+		}
+
+		var (
+			required, post, omitEmpty, named bool
+			src                              source
+		)
 
-		if n := f.Tag.Get("form"); n == "-" {
+		if n := f.Tag.Get(d.tag); n == "-" {
 			continue
 		} else if n != "" {
 			if p := strings.IndexByte(n, ','); p >= 0 {
 				if p > 0 {
 					name = n[:p]
+					named = true
 				}
 
 				rest := n[p:]
-				required = strings.Contains(rest, ",required,") || strings.HasSuffix(rest, ",required")
-				post = strings.Contains(rest, ",post,") || strings.HasSuffix(rest, ",post")
+				required = hasOption(rest, "required")
+				post = hasOption(rest, "post")
+				omitEmpty = hasOption(rest, "omitempty")
+
+				if hasOption(rest, "query") || hasOption(rest, "get") {
+					src = sourceQuery
+				} else if hasOption(rest, "header") {
+					src = sourceHeader
+				} else if hasOption(rest, "cookie") {
+					src = sourceCookie
+				} else if hasOption(rest, "path") {
+					src = sourcePath
+				}
 			} else {
 				name = n
+				named = true
 			}
 		}
 
-		var p processor
+		if d.caseInsensitive && (src == sourceForm || src == sourceQuery) {
+			name = strings.ToLower(name)
+		}
+
+		p, err := d.valueProcessor(f.Type, f.Tag)
+
+		var kp keysProcessor
 
-		if f.Type.Implements(interType) {
-			p = inter(false)
-		} else if reflect.PtrTo(f.Type).Implements(interType) {
-			p = inter(true)
-		} else if k := f.Type.Kind(); k == reflect.Slice || k == reflect.Ptr {
+		switch k := f.Type.Kind(); {
+		case p != nil:
+		case f.Type == fileType || f.Type == fileSliceType:
+			kp, err = newFile(f.Tag, f.Type == fileSliceType)
+		case k == reflect.Slice:
 			et := f.Type.Elem()
 
-			s := basicTypeProcessor(et, f.Tag)
-			if s == nil {
+			if s, serr := d.valueProcessor(et, f.Tag); s != nil {
+				p, err = newSlice(f.Tag, s, reflect.SliceOf(et))
+				err = errors.Join(serr, err)
+			} else if et.Kind() == reflect.Struct {
+				eti, terr := d.createTypeInfo(et, built)
+				if terr != nil {
+					return terr
+				}
+
+				tagErrs = append(tagErrs, eti.tagErr)
+				ti.usesQuery = ti.usesQuery || eti.usesQuery || eti.fields == nil
+				kp, err = newStructSlice(f.Type, f.Tag, d.maxIndex)
+			} else {
+				tagErrs = append(tagErrs, &TagError{Type: t, Field: f.Name, Tag: d.tag, Err: errors.ErrUnsupported})
+
 				continue
 			}
+		case k == reflect.Map && f.Type.Key().Kind() == reflect.String:
+			mp, merr := d.valueProcessor(f.Type.Elem(), f.Tag)
+			if mp == nil {
+				tagErrs = append(tagErrs, &TagError{Type: t, Field: f.Name, Tag: d.tag, Err: errors.ErrUnsupported})
+
+				continue
+			}
+
+			kp, err = newMapping(f.Type, f.Tag, mp, d.maxKeys)
+			err = errors.Join(merr, err)
+		case k == reflect.Struct:
+			if _, ok := f.Tag.Lookup("default"); ok {
+				return &TagError{Type: t, Field: f.Name, Tag: "default", Err: errors.ErrUnsupported}
+			}
+
+			inner, err := d.createTypeInfo(f.Type, built)
+			if err != nil {
+				return err
+			}
 
-			if k == reflect.Slice {
-				p = slice{
-					processor: s,
-					typ:       reflect.SliceOf(et),
+			tagErrs = append(tagErrs, inner.tagErr)
+			ti.usesQuery = ti.usesQuery || inner.usesQuery
+
+			if f.Anonymous && !named {
+				vs = append(vs, prefixValidators(inner.validators, i, "", t.Implements(validatorType) || reflect.PtrTo(t).Implements(validatorType))...)
+
+				for n, p := range inner.typeMap {
+					if _, ok := tm[n]; !ok {
+						tm[n] = processorDetails{
+							processor: p.processor,
+							Keys:      p.Keys,
+							Required:  p.Required,
+							Post:      p.Post,
+							OmitEmpty: p.OmitEmpty,
+							Source:    p.Source,
+							Index:     append(append(make([]int, 0, len(p.Index)+1), i), p.Index...),
+							Default:   p.Default,
+							Message:   p.Message,
+						}
+					}
 				}
 			} else {
-				p = pointer{
-					processor: s,
-					typ:       et,
-				}
-			}
-		} else if k == reflect.Struct && f.Anonymous {
-			for n, p := range createTypeMap(f.Type) {
-				if _, ok := tm[n]; !ok {
-					tm[n] = processorDetails{
+				vs = append(vs, prefixValidators(inner.validators, i, name+".", false)...)
+
+				for n, p := range inner.typeMap {
+					if p.Source == sourceForm {
+						p.Source = src
+					}
+
+					tm[name+"."+n] = processorDetails{
 						processor: p.processor,
+						Keys:      p.Keys,
 						Required:  p.Required,
-						Post:      p.Post,
+						Post:      p.Post || post,
+						OmitEmpty: p.OmitEmpty,
+						Source:    p.Source,
 						Index:     append(append(make([]int, 0, len(p.Index)+1), i), p.Index...),
+						Default:   p.Default,
+						Message:   p.Message,
 					}
 				}
 			}
 
 			continue
-		} else {
-			if p = basicTypeProcessor(f.Type, f.Tag); p == nil {
-				continue
+		default:
+			tagErrs = append(tagErrs, &TagError{Type: t, Field: f.Name, Tag: d.tag, Err: errors.ErrUnsupported})
+
+			continue
+		}
+
+		tagErrs = append(tagErrs, setTagField(err, t, f.Name))
+
+		var def []string
+
+		if dv, ok := f.Tag.Lookup("default"); ok {
+			if kp != nil {
+				return &TagError{Type: t, Field: f.Name, Tag: "default", Err: errors.ErrUnsupported}
+			}
+
+			if f.Type.Kind() == reflect.Slice {
+				def = strings.Split(dv, ",")
+			} else {
+				def = []string{dv}
+			}
+
+			if err := p.process(reflect.New(f.Type).Elem(), def, values{dec: d}); err != nil {
+				return &TagError{Type: t, Field: f.Name, Tag: "default", Err: err}
 			}
 		}
 
 		tm[name] = processorDetails{
 			processor: p,
+			Keys:      kp,
 			Required:  required,
 			Post:      post,
+			OmitEmpty: omitEmpty,
+			Source:    src,
 			Index:     []int{i},
+			Default:   def,
+			Message:   f.Tag.Get("msg"),
 		}
 	}
 
-	typeMaps[t] = tm
+	ti.fields = make([]field, 0, len(tm))
+
+	for _, key := range tm.orderedKeys() {
+		pd := tm[key]
+		ti.fields = append(ti.fields, field{Key: key, processorDetails: pd})
+		ti.usesQuery = ti.usesQuery || pd.Source == sourceQuery
+	}
+
+	ti.validators = d.validatorsFor(t, vs)
+	ti.tagErr = errors.Join(tagErrs...)
 
-	return tm
+	return nil
 }
 
 // Process parses the form data from the request into the passed value, which
@@ -162,79 +481,311 @@ func createTypeMap(t reflect.Type) typeMap {
 // of the Request, and the 'required' option will have an error thrown if the
 // key in not set.
 //
+// The 'query' option, or its alias 'get', forces the processor to parse a
+// value from only the query string of the URL. Values for other fields are
+// parsed from both the query string and the post data, with any key set in both
+// handled according to the Conflict option of the Decoder, which by default
+// uses the combined values, with post values first.
+//
+// The 'header', 'cookie' and 'path' options can be added to the form tag to
+// instead parse the value from, respectively, a request header, a cookie, or a
+// path value set by an http.ServeMux pattern. When processing values without a
+// request, such fields are treated as missing.
+//
+// The 'default' tag sets a value to be used when the key is not set, which is
+// parsed in the same way as a value from the request, with the default for a
+// slice being split on commas. A default satisfies the 'required' option. An
+// invalid default, or a default on a type that does not support one, such as a
+// map, is reported as a *TagError when the struct type is first processed.
+//
 // Number types can also have minimums and maximums checked during processing
 // by setting the 'min' and 'max' tags accordingly.
 //
 // In a similar vein, string types can utilise the 'regex' tag to set a
 // regular expression to be matched against.
 //
+// String and number types can be restricted to a set of choices with the
+// 'oneof' tag, which takes a space separated list of values, for example,
+// `oneof:"red green blue"`. A value not in the list returns a *ChoiceError,
+// which lists the allowed values. Numbers are compared by value, so, for an
+// int, '010' matches the choice '10'. Named constants can instead be processed
+// from their labels by registering them with the Enum option of a Decoder.
+//
+// The length of strings, counted in runes, can be limited with the 'minlen'
+// and 'maxlen' tags, and the number of values for a slice of basic types with
+// the 'mincount' and 'maxcount' tags, which are checked before the slice is
+// allocated.
+//
+// Fields are processed in the order in which they are declared, with the
+// fields of nested and embedded structs processed in the place of the struct
+// field, so ParseForm methods are called, and StopOnError stops, in the same
+// order on every call.
+//
 // Anonymous structs are traversed, but will not override more local fields.
 //
+// Named struct fields, and anonymous structs given a name with the 'form' tag,
+// are also traversed, with the keys of the inner fields prefixed by the name
+// of the struct field and a period, for example, the following struct would
+// parse the 'address.city' and 'address.zip' keys. The 'post' option, when
+// set on the struct field, applies to all of the inner fields.
+//
+// type Example struct {
+//	Address struct {
+//		City string `form:"city"`
+//		Zip  string `form:"zip,required"`
+//	} `form:"address"`
+// }
+//
 // Slices of basic types can be processed, and errors returned from any such
 // processing will be of the Errors type, which each indexed entry
 // corresponding to the index of the processed data.
 //
+// Slices of structs are processed from indexed keys, such as 'items[0].name'
+// and 'items[1].qty', with the inner keys following the same rules as the
+// outer struct. Indexes may be sparse or out of order, with unset entries being
+// left as the zero value, but may not be greater than the value set with the
+// 'maxindex' tag (default 1000). Indexes with a sign or leading zeros, such
+// as 'items[01].name', are not treated as indexed keys. Errors are returned as
+// an Errors type, with each indexed entry being an ErrorMap of the errors for
+// that entry.
+//
+// Maps with string keys and values of a basic type, a pointer to a basic type,
+// or a type with a custom data processor (see below), are processed from
+// bracketed keys, such as 'prefs[colour]', with empty keys being ignored. Map
+// keys can be restricted with a regular expression set with the 'keyregex'
+// tag, and the number of entries limited by the 'maxkeys' tag (default 1000).
+// Errors are returned as an ErrorMap, keyed by the map key.
+//
+// Uploaded files can be processed into fields of type *multipart.FileHeader
+// and []*multipart.FileHeader. The 'maxsize' tag limits the size, in bytes,
+// of each file, the 'maxfiles' tag limits the number of files for a slice, and
+// the 'accept' tag sets a space separated list of allowed content types, which
+// may be wildcards such as 'image/*'. The content type is detected from the
+// first bytes of the file, not from the type sent by the client. Errors
+// returned for a slice will be of the Errors type.
+//
+// Fields of type time.Time are parsed using the layout set with the 'layout'
+// tag, or, if no layout is set, using the formats of the HTML date,
+// datetime-local, time, month and week input types. Inputs without a time zone
+// are parsed in the location set with the 'tz' tag, or the location passed to
+// ProcessIn, defaulting to UTC. The 'min' and 'max' tags can be set, using the
+// same layouts, to limit the range of accepted times, and are interpreted in
+// the same location as the inputs.
+//
+// Fields of type time.Duration are parsed using the time.ParseDuration
+// syntax, such as '1h30m', which is also used for the 'min' and 'max' tags.
+// Setting the 'unit' tag, for example to 's' or 'm', additionally allows plain
+// numbers, which are then counted in that unit.
+//
 // Pointers to basic types can also be processed, with the type being allocated
 // even if an error occurs.
 //
+// After all of the fields are processed, a ValidateForm method, with the
+// following specification, is called on the struct, and on any nested or
+// embedded structs, to allow for checks across fields:
+//
+// ValidateForm() error
+//
+// When the returned error is an ErrorMap, its entries are added to the
+// returned ErrorMap, with the keys prefixed as for the fields of a nested
+// struct; otherwise the error is added with the key of the struct, which is
+// empty for the passed struct. Methods on nested structs are called first, and
+// a method on an embedded struct is not called separately when promoted to
+// the outer struct. The methods are not called when there are errors from
+// processing the fields, unless the AlwaysValidate option is set on the
+// Decoder. Structs in slices are validated in the same way, per entry.
+//
+// The 'msg' tag sets a message that replaces the message of any error for the
+// field when creating a Problem, see WriteProblem.
+//
+// Errors for individual keys are returned in an ErrorMap. Values outside of
+// the 'min' and 'max' range return a *RangeError, strings not matching the
+// 'regex' tag return a *PatternError, and values that cannot be parsed return a
+// *ParseError, which hold the details of the rejected value and match,
+// respectively, ErrNotInRange, ErrNoMatch and the underlying error with
+// errors.Is. Lengths and counts outside of their limits return a *LengthError
+// or *CountError, matching ErrTooShort or ErrTooLong, and ErrTooFew or
+// ErrTooMany. Both ErrorMap and Errors can be unwrapped, so errors.Is and
+// errors.As can be used on the returned error directly.
+//
+// Keys that are not processed into any field are ignored, unless the Strict
+// option is set on the Decoder, and can be listed with UnusedKeys.
+//
+// Tags with invalid values, and fields of types that cannot be processed, are
+// ignored by Process, but are reported by NewBinder, which creates a Binder
+// that processes a single type without looking up its type information on
+// each call.
+//
+// For frequently processed types, the formgen command can generate
+// ParseFormRequest and EncodeForm methods that process and encode the type
+// without reflection, and which are used in place of the reflective processing
+// by Process and Encode.
+//
+// Process uses a default Decoder, see NewDecoder for creating a Decoder with
+// different options.
+//
 // Lastly, a custom data processor can be specified by attaching a method to
 // the field type with the following specification:
 //
 // ParseForm([]string) error.
+//
+// Types implementing encoding.TextUnmarshaler, such as netip.Addr and big.Int,
+// will have the UnmarshalText method called with the first value.
+//
+// When a type could be processed in multiple ways, a ParseForm method takes
+// precedence, followed by the built-in time.Time and time.Duration processors,
+// then an UnmarshalText method, and lastly the processor for the kind of the
+// type. This also applies to the elements of slices, maps and pointers.
 func Process(r *http.Request, fv interface{}) error {
-	v := reflect.ValueOf(fv)
-	if v.Kind() != reflect.Ptr {
-		return ErrNeedPointer
-	}
+	return defaultDecoder.Process(r, fv)
+}
 
-	for v.Kind() == reflect.Ptr {
-		v = v.Elem()
-	}
+// ProcessIn acts like Process, but uses the given location when parsing times
+// without a time zone, unless overridden with a 'tz' tag.
+func ProcessIn(r *http.Request, fv interface{}, loc *time.Location) error {
+	return defaultDecoder.ProcessIn(r, fv, loc)
+}
+
+// ProcessValues parses the given values into the passed value, which must be
+// a pointer to a struct, using the same rules as Process.
+//
+// As there is no distinction between query and post values, fields with the
+// 'post' or 'query' options are also parsed from the given values. Keys with
+// no values are treated as missing.
+func ProcessValues(vals url.Values, fv interface{}) error {
+	return defaultDecoder.ProcessValues(vals, fv)
+}
+
+// ProcessQueryPost parses the given query and post values into the passed
+// value, which must be a pointer to a struct, using the same rules as Process.
+//
+// The values are combined as they would be for the Form field of an
+// http.Request, with post values preceding query values, and fields with the
+// 'post' option being parsed from only the post values.
+func ProcessQueryPost(query, post url.Values, fv interface{}) error {
+	return defaultDecoder.ProcessQueryPost(query, post, fv)
+}
+
+type values struct {
+	form, post url.Values
+	query      url.Values
+	split      bool
+	files      map[string][]*multipart.FileHeader
+	req        *http.Request
+	dec        *Decoder
+	loc        *time.Location
+}
+
+func (v values) get(pd processorDetails, key string) ([]string, bool, error) {
+	switch pd.Source {
+	case sourceQuery:
+		val := v.query[key]
+
+		return val, len(val) > 0, nil
+	case sourceHeader:
+		if v.req != nil {
+			if val := v.req.Header.Values(key); len(val) > 0 {
+				return val, true, nil
+			}
+		}
+	case sourceCookie:
+		if v.req != nil {
+			var val []string
+
+			for _, c := range v.req.Cookies() {
+				if c.Name == key || v.dec.caseInsensitive && strings.EqualFold(c.Name, key) {
+					val = append(val, c.Value)
+				}
+			}
+
+			return val, len(val) > 0, nil
+		}
+	case sourcePath:
+		if v.req != nil {
+			if val := v.req.PathValue(key); val != "" {
+				return []string{val}, true, nil
+			}
+		}
+	default:
+		if pd.Post {
+			val := v.post[key]
 
-	if v.Kind() != reflect.Struct {
-		return ErrNeedStruct
+			return val, len(val) > 0, nil
+		}
+
+		return v.formValue(key)
 	}
 
-	tm := getTypeMap(v.Type())
+	return nil, false, nil
+}
 
-	if err := r.ParseForm(); err != nil {
-		return err
+// formValue returns the form values for the key, applying the conflict policy
+// of the Decoder when the key is in both the post and query values. Keys with
+// no values are treated as missing.
+func (v values) formValue(key string) ([]string, bool, error) {
+	if v.split && v.dec.conflict != ConflictMerge {
+		post := v.post[key]
+		query := v.query[key]
+
+		if len(post) > 0 && len(query) > 0 {
+			switch v.dec.conflict {
+			case ConflictPreferPost:
+				return post, true, nil
+			case ConflictPreferQuery:
+				return query, true, nil
+			default:
+				return nil, true, ErrConflict
+			}
+		}
 	}
 
+	val := v.form[key]
+
+	return val, len(val) > 0, nil
+}
+
+func (ti *typeInfo) process(v reflect.Value, vals values) ErrorMap {
 	var errors ErrorMap
 
-	for key, pd := range tm {
+	for n := range ti.fields {
 		var (
-			val []string
+			key = ti.fields[n].Key
+			pd  = &ti.fields[n].processorDetails
+			err error
 			ok  bool
 		)
 
-		if pd.Post {
-			val, ok = r.PostForm[key]
-		} else {
-			val, ok = r.Form[key]
-		}
-
-		if ok {
-			if err := pd.processor.process(v.FieldByIndex(pd.Index), val); err != nil {
-				if errors == nil {
-					errors = make(ErrorMap)
-				}
+		if pd.Keys != nil {
+			vs := vals
 
-				errors[key] = err
+			if pd.Post {
+				vs.form, vs.query = vs.post, nil
+			} else if pd.Source == sourceQuery {
+				vs.form, vs.post = vs.query, nil
 			}
-		} else if pd.Required {
-			if errors == nil {
-				errors = make(ErrorMap)
+
+			ok, err = pd.Keys.processKeys(v.FieldByIndex(pd.Index), key, vs)
+		} else {
+			var val []string
+
+			if val, ok, err = vals.get(*pd, key); ok && err == nil {
+				err = pd.processor.process(v.FieldByIndex(pd.Index), val, vals)
+			} else if !ok && err == nil && pd.Default != nil {
+				err = pd.processor.process(v.FieldByIndex(pd.Index), pd.Default, vals)
+				ok = true
 			}
+		}
 
-			errors[key] = ErrRequiredMissing
+		if err != nil {
+			errors = AddError(errors, key, err)
+		} else if !ok && pd.Required {
+			errors = AddError(errors, key, ErrRequiredMissing)
 		}
-	}
 
-	if len(errors) > 0 {
-		return errors
+		if len(errors) > 0 && vals.dec.stopOnError {
+			break
+		}
 	}
 
-	return nil
+	return errors
 }
@@ -1,14 +1,22 @@
 package form
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"math"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 type Z struct {
@@ -25,6 +33,59 @@ type X struct {
 	A string
 }
 
+type Upper string
+
+func (u *Upper) ParseForm(data []string) error {
+	*u = Upper(strings.ToUpper(data[0]))
+
+	return nil
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+type Text string
+
+func (t *Text) UnmarshalText(data []byte) error {
+	*t = Text("text:" + string(data))
+
+	return nil
+}
+
+type Both string
+
+func (b *Both) ParseForm(data []string) error {
+	*b = Both("form:" + data[0])
+
+	return nil
+}
+
+func (b *Both) UnmarshalText(data []byte) error {
+	*b = Both("text:" + string(data))
+
+	return nil
+}
+
+func bigInt(s string) *big.Int {
+	b, _ := new(big.Int).SetString(s, 10)
+
+	return b
+}
+
+type Row struct {
+	Name string `form:"name,required"`
+	Qty  int    `form:"qty" max:"10"`
+}
+
 func TestCreateTypeMap(t *testing.T) {
 	for n, test := range [...]struct {
 		Input  reflect.Type
@@ -479,9 +540,193 @@ func TestCreateTypeMap(t *testing.T) {
 				},
 			},
 		},
+		{ // 32
+			Input: reflect.TypeOf(struct {
+				B X
+			}{}),
+			Output: typeMap{
+				"B.A": {
+					processor: str{},
+					Index:     []int{0, 0},
+				},
+			},
+		},
+		{ // 33
+			Input: reflect.TypeOf(struct {
+				Y `form:"y,post"`
+			}{}),
+			Output: typeMap{
+				"y.A": {
+					processor: str{},
+					Post:      true,
+					Index:     []int{0, 0, 0},
+				},
+				"y.B": {
+					processor: boolean{},
+					Post:      true,
+					Index:     []int{0, 1},
+				},
+			},
+		},
+		{ // 34
+			Input: reflect.TypeOf(struct {
+				A []X `maxindex:"10"`
+			}{}),
+			Output: typeMap{
+				"A": {
+					Keys: structSlice{
+						typ:      reflect.TypeOf([]X{}),
+						maxIndex: 10,
+					},
+					Index: []int{0},
+				},
+			},
+		},
+		{ // 35
+			Input: reflect.TypeOf(struct {
+				A map[string]*int `keyregex:"^[a-z]+$" maxkeys:"5" min:"1"`
+				B map[int]int
+				C map[string]X
+			}{}),
+			Output: typeMap{
+				"A": {
+					Keys: mapping{
+						processor: pointer{
+							processor: inum{
+								min:  1,
+								max:  math.MaxInt64,
+								bits: 64,
+							},
+							typ: reflect.TypeOf(0),
+						},
+						typ:      reflect.TypeOf(map[string]*int{}),
+						keyRegex: regexp.MustCompile("^[a-z]+$"),
+						maxKeys:  5,
+					},
+					Index: []int{0},
+				},
+			},
+		},
+		{ // 36
+			Input: reflect.TypeOf(struct {
+				A *multipart.FileHeader   `form:",required" accept:"image/png image/gif"`
+				B []*multipart.FileHeader `maxsize:"1024" maxfiles:"3"`
+			}{}),
+			Output: typeMap{
+				"A": {
+					Keys: file{
+						maxSize:  math.MaxInt64,
+						maxFiles: math.MaxInt32,
+						accept:   []string{"image/png", "image/gif"},
+					},
+					Required: true,
+					Index:    []int{0},
+				},
+				"B": {
+					Keys: file{
+						maxSize:  1024,
+						maxFiles: 3,
+						multiple: true,
+					},
+					Index: []int{1},
+				},
+			},
+		},
+		{ // 37
+			Input: reflect.TypeOf(struct {
+				A time.Time `layout:"2006" min:"2000"`
+				B []time.Time
+			}{}),
+			Output: typeMap{
+				"A": {
+					processor: timestamp{
+						layouts: []string{"2006"},
+						min:     time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+					Index: []int{0},
+				},
+				"B": {
+					processor: slice{
+						processor: timestamp{
+							layouts: defaultLayouts,
+						},
+						typ: reflect.TypeOf([]time.Time{}),
+					},
+					Index: []int{1},
+				},
+			},
+		},
+		{ // 38
+			Input: reflect.TypeOf(struct {
+				A time.Duration `unit:"m" min:"1" max:"1h"`
+			}{}),
+			Output: typeMap{
+				"A": {
+					processor: duration{
+						min:  time.Minute,
+						max:  time.Hour,
+						unit: time.Minute,
+					},
+					Index: []int{0},
+				},
+			},
+		},
+		{ // 39
+			Input: reflect.TypeOf(struct {
+				A Text
+				B *Both
+				C []*Text
+				D net.IP
+			}{}),
+			Output: typeMap{
+				"A": {
+					processor: text(true),
+					Index:     []int{0},
+				},
+				"B": {
+					processor: inter(false),
+					Index:     []int{1},
+				},
+				"C": {
+					processor: slice{
+						processor: pointer{
+							processor: text(true),
+							typ:       reflect.TypeOf(Text("")),
+						},
+						typ: reflect.TypeOf([]*Text{}),
+					},
+					Index: []int{2},
+				},
+				"D": {
+					processor: text(true),
+					Index:     []int{3},
+				},
+			},
+		},
+		{ // 40
+			Input: reflect.TypeOf(struct {
+				A []string `minlen:"2" maxlen:"5" mincount:"1" maxcount:"3"`
+			}{}),
+			Output: typeMap{
+				"A": {
+					processor: slice{
+						processor: str{
+							minLen: 2,
+							maxLen: 5,
+						},
+						typ:      reflect.TypeOf([]string{}),
+						minCount: 1,
+						maxCount: 3,
+					},
+					Index: []int{0},
+				},
+			},
+		},
 	} {
-		output := createTypeMap(test.Input)
-		if !reflect.DeepEqual(output, test.Output) {
+		output, err := defaultDecoder.createTypeMap(test.Input)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if !reflect.DeepEqual(output, test.Output) {
 			t.Errorf("test %d: expecting output %v, got %v", n+1, test.Output, output)
 		}
 	}
@@ -562,7 +807,7 @@ func TestProcess(t *testing.T) {
 				A int `min:"15"`
 			}{},
 			ErrorMap{
-				"A": ErrNotInRange,
+				"A": &RangeError{Min: int64(15), Value: int64(10)},
 			},
 		},
 		{ // 7
@@ -574,7 +819,7 @@ func TestProcess(t *testing.T) {
 				A int `max:"5"`
 			}{},
 			ErrorMap{
-				"A": ErrNotInRange,
+				"A": &RangeError{Max: int64(5), Value: int64(10)},
 			},
 		},
 		{ // 8
@@ -598,7 +843,7 @@ func TestProcess(t *testing.T) {
 				A uint `min:"15"`
 			}{},
 			ErrorMap{
-				"A": ErrNotInRange,
+				"A": &RangeError{Min: uint64(15), Value: uint64(10)},
 			},
 		},
 		{ // 10
@@ -610,7 +855,7 @@ func TestProcess(t *testing.T) {
 				A uint `max:"5"`
 			}{},
 			ErrorMap{
-				"A": ErrNotInRange,
+				"A": &RangeError{Max: uint64(5), Value: uint64(10)},
 			},
 		},
 		{ // 11
@@ -700,7 +945,7 @@ func TestProcess(t *testing.T) {
 				A bool
 			}{},
 			ErrorMap{
-				"A": ErrInvalidBoolean,
+				"A": &ParseError{Key: "A", Value: "!", Err: ErrInvalidBoolean},
 			},
 		},
 		{ // 19
@@ -712,7 +957,7 @@ func TestProcess(t *testing.T) {
 				A float64 `min:"15"`
 			}{},
 			ErrorMap{
-				"A": ErrNotInRange,
+				"A": &RangeError{Min: float64(15), Value: float64(10)},
 			},
 		},
 		{ // 20
@@ -724,7 +969,7 @@ func TestProcess(t *testing.T) {
 				A float64 `max:"5"`
 			}{},
 			ErrorMap{
-				"A": ErrNotInRange,
+				"A": &RangeError{Max: float64(5), Value: float64(10)},
 			},
 		},
 		{ // 21
@@ -772,7 +1017,7 @@ func TestProcess(t *testing.T) {
 				A string `regex:"hello"`
 			}{},
 			ErrorMap{
-				"A": ErrNoMatch,
+				"A": &PatternError{Pattern: "hello", Value: "HELLO, WORLD"},
 			},
 		},
 		{ // 25
@@ -817,7 +1062,7 @@ func TestProcess(t *testing.T) {
 			},
 			ErrorMap{
 				"A": Errors{
-					ErrNoMatch,
+					&PatternError{Pattern: "Beep", Value: "HELLO, WORLD"},
 					nil,
 				},
 			},
@@ -844,30 +1089,1037 @@ func TestProcess(t *testing.T) {
 			},
 			nil,
 		},
-	} {
-		r := http.Request{
-			Method: http.MethodPost,
-			URL: &url.URL{
-				RawQuery: test.Get.Encode(),
+		{ // 28
+			url.Values{
+				"address.City": []string{"London"},
+				"address.zip":  []string{"N1"},
+				"age":          []string{"20"},
 			},
-			Header: http.Header{
-				"Content-Type": []string{"application/x-www-form-urlencoded"},
+			url.Values{},
+			struct {
+				Address struct {
+					City string
+					Zip  string `form:"zip" regex:"^[A-Z]"`
+				} `form:"address"`
+				Age int `form:"age"`
+			}{
+				Address: struct {
+					City string
+					Zip  string `form:"zip" regex:"^[A-Z]"`
+				}{
+					City: "London",
+					Zip:  "N1",
+				},
+				Age: 20,
 			},
-			Body: io.NopCloser(strings.NewReader(test.Post.Encode())),
-		}
-		output := reflect.New(reflect.TypeOf(test.Output))
-		err := Process(&r, output.Interface())
-		if err != nil {
-			if test.Err == nil {
-				t.Errorf("test %d: unexpected error: %s", n+1, err)
-			} else if !reflect.DeepEqual(err, test.Err) {
-				t.Errorf("test %d: expecting error: %s\ngot: %s", n+1, test.Err, err)
-			}
-		} else if test.Err != nil {
-			t.Errorf("test %d: got no error when expecting: %s", n+1, test.Err)
-		}
-		if o := output.Elem().Interface(); !reflect.DeepEqual(o, test.Output) {
-			t.Errorf("test %d: expecting output: %#v\ngot: %#v", n+1, test.Output, o)
-		}
+			nil,
+		},
+		{ // 29
+			url.Values{
+				"a.b.C": []string{"100"},
+			},
+			url.Values{},
+			struct {
+				A struct {
+					B struct {
+						C int `max:"10"`
+						D int `form:",required"`
+					} `form:"b"`
+				} `form:"a"`
+			}{},
+			ErrorMap{
+				"a.b.C": &RangeError{Max: int64(10), Value: int64(100)},
+				"a.b.D": ErrRequiredMissing,
+			},
+		},
+		{ // 30
+			url.Values{
+				"items[2].name": []string{"Bob"},
+				"items[0].name": []string{"Alice"},
+				"items[0].qty":  []string{"1"},
+				"items[2].qty":  []string{"3"},
+				"items[a].qty":  []string{"4"},
+			},
+			url.Values{},
+			struct {
+				Items []Row `form:"items"`
+			}{
+				Items: []Row{
+					{Name: "Alice", Qty: 1},
+					{},
+					{Name: "Bob", Qty: 3},
+				},
+			},
+			nil,
+		},
+		{ // 31
+			url.Values{
+				"items[0].name": []string{"Alice"},
+				"items[0].qty":  []string{"100"},
+				"items[1].qty":  []string{"2"},
+			},
+			url.Values{},
+			struct {
+				Items []Row `form:"items"`
+			}{
+				Items: []Row{
+					{Name: "Alice"},
+					{Qty: 2},
+				},
+			},
+			ErrorMap{
+				"items": Errors{
+					ErrorMap{
+						"qty": &RangeError{Max: int64(10), Value: int64(100)},
+					},
+					ErrorMap{
+						"name": ErrRequiredMissing,
+					},
+				},
+			},
+		},
+		{ // 32
+			url.Values{
+				"items[0].name":         []string{"Alice"},
+				"items[999999999].name": []string{"Bob"},
+			},
+			url.Values{},
+			struct {
+				Items []Row `form:"items"`
+			}{},
+			ErrorMap{
+				"items": ErrInvalidIndex,
+			},
+		},
+		{ // 33
+			url.Values{
+				"items[1].name": []string{"Bob"},
+			},
+			url.Values{},
+			struct {
+				Items []Row `form:"items" maxindex:"1"`
+				List  []Row `form:"list,required"`
+			}{
+				Items: []Row{
+					{},
+					{Name: "Bob"},
+				},
+			},
+			ErrorMap{
+				"list": ErrRequiredMissing,
+			},
+		},
+		{ // 34
+			url.Values{
+				"prefs[colour]": []string{"red"},
+				"prefs[size]":   []string{"large"},
+				"prefs[a][b]":   []string{"ignored"},
+				"prefs[]":       []string{"ignored"},
+				"prefs":         []string{"ignored"},
+				"upper[a]":      []string{"hello"},
+			},
+			url.Values{},
+			struct {
+				Prefs map[string]string `form:"prefs"`
+				Upper map[string]Upper  `form:"upper"`
+				None  map[string]string `form:"none"`
+			}{
+				Prefs: map[string]string{
+					"colour": "red",
+					"size":   "large",
+				},
+				Upper: map[string]Upper{
+					"a": "HELLO",
+				},
+			},
+			nil,
+		},
+		{ // 35
+			url.Values{
+				"nums[a]": []string{"1"},
+				"nums[b]": []string{"100"},
+				"nums[C]": []string{"2"},
+			},
+			url.Values{},
+			struct {
+				Nums map[string]*int `form:"nums" keyregex:"^[a-z]$" max:"10"`
+			}{
+				Nums: map[string]*int{
+					"a": intPtr(1),
+				},
+			},
+			ErrorMap{
+				"nums": ErrorMap{
+					"b": &RangeError{Max: int64(10), Value: int64(100)},
+					"C": ErrInvalidKey,
+				},
+			},
+		},
+		{ // 36
+			url.Values{
+				"nums[a]": []string{"1"},
+				"nums[b]": []string{"2"},
+				"nums[c]": []string{"3"},
+			},
+			url.Values{},
+			struct {
+				Nums map[string]int `form:"nums,required" maxkeys:"2"`
+			}{},
+			ErrorMap{
+				"nums": ErrTooManyKeys,
+			},
+		},
+		{ // 37
+			url.Values{
+				"A": []string{"2020-02-03"},
+				"B": []string{"2020-02-03T04:05"},
+				"C": []string{"2020-02-03T04:05:06.5"},
+				"D": []string{"13:14"},
+				"E": []string{"2020-02"},
+				"F": []string{"2021-W01", "2020-W53"},
+				"G": []string{"03/02/2020"},
+			},
+			url.Values{},
+			struct {
+				A time.Time
+				B time.Time
+				C *time.Time
+				D time.Time
+				E time.Time
+				F []time.Time
+				G time.Time `layout:"02/01/2006"`
+			}{
+				A: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+				B: time.Date(2020, 2, 3, 4, 5, 0, 0, time.UTC),
+				C: timePtr(time.Date(2020, 2, 3, 4, 5, 6, 500000000, time.UTC)),
+				D: time.Date(0, 1, 1, 13, 14, 0, 0, time.UTC),
+				E: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+				F: []time.Time{
+					time.Date(2021, 1, 4, 0, 0, 0, 0, time.UTC),
+					time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC),
+				},
+				G: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+			},
+			nil,
+		},
+		{ // 38
+			url.Values{
+				"A": []string{"2020-02-03"},
+				"B": []string{"2020-02-03"},
+				"C": []string{"2020-02-03"},
+				"D": []string{"2021-W53"},
+				"E": []string{"03/02/2020"},
+			},
+			url.Values{},
+			struct {
+				A time.Time `min:"2020-02-04"`
+				B time.Time `max:"2020-02-02"`
+				C time.Time `min:"2020-02-03" max:"2020-02-03"`
+				D time.Time
+				E time.Time `layout:"2006-01-02"`
+			}{
+				C: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC),
+			},
+			ErrorMap{
+				"A": &RangeError{Min: time.Date(2020, 2, 4, 0, 0, 0, 0, time.UTC), Value: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC)},
+				"B": &RangeError{Max: time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC), Value: time.Date(2020, 2, 3, 0, 0, 0, 0, time.UTC)},
+				"D": &ParseError{Key: "D", Value: "2021-W53", Err: ErrInvalidTime},
+				"E": &ParseError{Key: "E", Value: "03/02/2020", Err: ErrInvalidTime},
+			},
+		},
+		{ // 39
+			url.Values{
+				"A": []string{"1h30m"},
+				"B": []string{"90"},
+				"C": []string{"1.5"},
+				"D": []string{"2m", "30"},
+				"E": []string{"10s"},
+			},
+			url.Values{},
+			struct {
+				A time.Duration
+				B time.Duration   `unit:"s"`
+				C time.Duration   `unit:"h" min:"1" max:"2h"`
+				D []time.Duration `unit:"m"`
+				E *time.Duration
+			}{
+				A: time.Hour + 30*time.Minute,
+				B: 90 * time.Second,
+				C: time.Hour + 30*time.Minute,
+				D: []time.Duration{2 * time.Minute, 30 * time.Minute},
+				E: durationPtr(10 * time.Second),
+			},
+			nil,
+		},
+		{ // 40
+			url.Values{
+				"A": []string{"90"},
+				"B": []string{"30m"},
+				"C": []string{"3"},
+			},
+			url.Values{},
+			struct {
+				A time.Duration
+				B time.Duration `min:"1h"`
+				C time.Duration `unit:"h" max:"2h"`
+			}{},
+			ErrorMap{
+				"A": &ParseError{
+					Key:   "A",
+					Value: "90",
+					Err: func() error {
+						_, err := time.ParseDuration("90")
+
+						return err
+					}(),
+				},
+				"B": &RangeError{Min: time.Hour, Value: 30 * time.Minute},
+				"C": &RangeError{Max: 2 * time.Hour, Value: 3 * time.Hour},
+			},
+		},
+		{ // 41
+			url.Values{
+				"A": []string{"127.0.0.1"},
+				"B": []string{"12345678901234567890"},
+				"C": []string{"::1", "10.0.0.1"},
+				"D": []string{"hello"},
+				"E": []string{"world"},
+				"F": []string{"2020-01-02"},
+				"G": []string{"abc"},
+			},
+			url.Values{},
+			struct {
+				A net.IP
+				B *big.Int
+				C []net.IP
+				D Both
+				E map[string]Text
+				F time.Time
+				G Text
+			}{
+				A: net.IPv4(127, 0, 0, 1),
+				B: bigInt("12345678901234567890"),
+				C: []net.IP{net.IPv6loopback, net.IPv4(10, 0, 0, 1)},
+				D: "form:hello",
+				F: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+				G: "text:abc",
+			},
+			nil,
+		},
+		{ // 42
+			url.Values{
+				"A": []string{"not an ip"},
+			},
+			url.Values{},
+			struct {
+				A net.IP
+			}{},
+			ErrorMap{
+				"A": &ParseError{Key: "A", Value: "not an ip", Err: &net.ParseError{Type: "IP address", Text: "not an ip"}},
+			},
+		},
+		{ // 43
+			url.Values{
+				"A":             []string{"1"},
+				"B":             []string{"3"},
+				"items[0].name": []string{"query"},
+			},
+			url.Values{
+				"A":             []string{"2"},
+				"B":             []string{"4"},
+				"items[0].name": []string{"post"},
+			},
+			struct {
+				A     int   `form:",query"`
+				B     []int `form:",get"`
+				Items []Row `form:"items,query"`
+			}{
+				A: 1,
+				B: []int{3},
+				Items: []Row{
+					{Name: "query"},
+				},
+			},
+			nil,
+		},
+		{ // 44
+			url.Values{
+				"A": []string{"ab"},
+				"B": []string{"héllo"},
+				"C": []string{"toolong"},
+				"D": []string{"a", "bc", "ab"},
+			},
+			url.Values{},
+			struct {
+				A string   `minlen:"3"`
+				B string   `minlen:"5" maxlen:"5"`
+				C string   `maxlen:"5"`
+				D []string `minlen:"2"`
+			}{
+				B: "héllo",
+				D: []string{"", "bc", "ab"},
+			},
+			ErrorMap{
+				"A": &LengthError{Min: 3, Len: 2},
+				"C": &LengthError{Max: 5, Len: 7},
+				"D": Errors{
+					&LengthError{Min: 2, Len: 1},
+					nil,
+					nil,
+				},
+			},
+		},
+		{ // 45
+			url.Values{
+				"A": []string{"1", "2", "3"},
+				"B": []string{"1"},
+				"C": []string{"1", "2"},
+			},
+			url.Values{},
+			struct {
+				A []int `maxcount:"2"`
+				B []int `mincount:"2"`
+				C []int `mincount:"2" maxcount:"2"`
+			}{
+				C: []int{1, 2},
+			},
+			ErrorMap{
+				"A": &CountError{Max: 2, Count: 3},
+				"B": &CountError{Min: 2, Count: 1},
+			},
+		},
+		{ // 46
+			url.Values{
+				"A": []string{"green"},
+				"B": []string{"yellow"},
+				"C": []string{"010"},
+				"D": []string{"3"},
+				"E": []string{"x"},
+			},
+			url.Values{},
+			struct {
+				A string `oneof:"red green blue"`
+				B string `oneof:"red green blue"`
+				C uint   `oneof:"5 10 20"`
+				D []int  `oneof:"1 2"`
+				E int    `oneof:"1 2"`
+			}{
+				A: "green",
+				C: 10,
+				D: []int{0},
+			},
+			ErrorMap{
+				"B": &ChoiceError{
+					Value:   "yellow",
+					Choices: []string{"red", "green", "blue"},
+				},
+				"D": Errors{
+					&ChoiceError{
+						Value:   "3",
+						Choices: []string{"1", "2"},
+					},
+				},
+				"E": &ParseError{
+					Key:   "E",
+					Value: "x",
+					Err: &strconv.NumError{
+						Func: "ParseInt",
+						Num:  "x",
+						Err:  strconv.ErrSyntax,
+					},
+				},
+			},
+		},
+	} {
+		r := http.Request{
+			Method: http.MethodPost,
+			URL: &url.URL{
+				RawQuery: test.Get.Encode(),
+			},
+			Header: http.Header{
+				"Content-Type": []string{"application/x-www-form-urlencoded"},
+			},
+			Body: io.NopCloser(strings.NewReader(test.Post.Encode())),
+		}
+		output := reflect.New(reflect.TypeOf(test.Output))
+		err := Process(&r, output.Interface())
+		if err != nil {
+			if test.Err == nil {
+				t.Errorf("test %d: unexpected error: %s", n+1, err)
+			} else if !reflect.DeepEqual(err, test.Err) {
+				t.Errorf("test %d: expecting error: %s\ngot: %s", n+1, test.Err, err)
+			}
+		} else if test.Err != nil {
+			t.Errorf("test %d: got no error when expecting: %s", n+1, test.Err)
+		}
+		if o := output.Elem().Interface(); !reflect.DeepEqual(o, test.Output) {
+			t.Errorf("test %d: expecting output: %#v\ngot: %#v", n+1, test.Output, o)
+		}
+		output = reflect.New(reflect.TypeOf(test.Output))
+		if err := ProcessQueryPost(test.Get, test.Post, output.Interface()); !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: ProcessQueryPost: expecting error: %v\ngot: %v", n+1, test.Err, err)
+		}
+		if o := output.Elem().Interface(); !reflect.DeepEqual(o, test.Output) {
+			t.Errorf("test %d: ProcessQueryPost: expecting output: %#v\ngot: %#v", n+1, test.Output, o)
+		}
+	}
+}
+
+func TestProcessValues(t *testing.T) {
+	var output struct {
+		A int `form:",post"`
+		B []string
+		C Row `form:"c"`
+	}
+
+	if err := ProcessValues(url.Values{
+		"A":      []string{"1"},
+		"B":      []string{"a", "b"},
+		"c.name": []string{"Alice"},
+	}, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if output.A != 1 || !reflect.DeepEqual(output.B, []string{"a", "b"}) || output.C.Name != "Alice" {
+		t.Errorf("unexpected output: %#v", output)
+	}
+
+	if err := ProcessValues(url.Values{}, output); err != ErrNeedPointer {
+		t.Errorf("expecting error %s, got %v", ErrNeedPointer, err)
+	}
+}
+
+func TestProcessValuesEmpty(t *testing.T) {
+	type empty struct {
+		A int               `form:"a"`
+		B string            `form:"b,required"`
+		C uint              `form:"c" default:"3"`
+		D []float64         `form:"d"`
+		E map[string]bool   `form:"e"`
+		F []Row             `form:"f"`
+		G time.Time         `form:"g"`
+		H map[string]string `form:"h"`
+	}
+
+	vals := url.Values{
+		"a":         {},
+		"b":         {},
+		"c":         {},
+		"d":         {},
+		"e[x]":      {},
+		"f[0].qty":  {},
+		"f[0].name": {"Alice"},
+		"g":         {},
+		"h[x]":      {},
+		"h[y]":      {"1"},
+	}
+
+	expected := empty{
+		C: 3,
+		F: []Row{{Name: "Alice"}},
+		H: map[string]string{"y": "1"},
+	}
+
+	for n, d := range [...]*Decoder{NewDecoder(), NewDecoder(Conflict(ConflictError))} {
+		var output empty
+
+		if err := d.ProcessQueryPost(vals, url.Values{"a": {}, "h[y]": {}}, &output); !reflect.DeepEqual(err, ErrorMap{"b": ErrRequiredMissing}) {
+			t.Errorf("test %d: expecting required error, got %v", n+1, err)
+		} else if !reflect.DeepEqual(output, expected) {
+			t.Errorf("test %d: expecting output %#v, got %#v", n+1, expected, output)
+		}
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	for n, test := range [...]struct {
+		Key   string
+		Index int
+		Rest  string
+		OK    bool
+	}{
+		{"items[0].name", 0, "name", true},
+		{"items[1].name", 1, "name", true},
+		{"items[10].name", 10, "name", true},
+		{"items[01].name", 0, "", false},
+		{"items[00].name", 0, "", false},
+		{"items[+1].name", 0, "", false},
+		{"items[-1].name", 0, "", false},
+		{"items[].name", 0, "", false},
+		{"items[1]", 0, "", false},
+		{"items[1].", 0, "", false},
+		{"other[1].name", 0, "", false},
+		{"items[99999999999].name", math.MaxInt32, "name", true},
+	} {
+		index, rest, ok := parseIndex(test.Key, "items[")
+		if index != test.Index || rest != test.Rest || ok != test.OK {
+			t.Errorf("test %d: expecting %d, %q, %v, got %d, %q, %v", n+1, test.Index, test.Rest, test.OK, index, rest, ok)
+		}
+	}
+}
+
+func TestProcessDurationUnitLimits(t *testing.T) {
+	for n, test := range [...]struct {
+		Input  string
+		Output time.Duration
+		Err    error
+	}{
+		{ // 1
+			Input: "NaN",
+			Err:   &ParseError{Key: "D", Value: "NaN", Err: strconv.ErrSyntax},
+		},
+		{ // 2
+			Input: "Inf",
+			Err:   &RangeError{Value: "Inf"},
+		},
+		{ // 3
+			Input: "-Inf",
+			Err:   &RangeError{Value: "-Inf"},
+		},
+		{ // 4
+			Input: "9223372036.854775807",
+			Err:   &RangeError{Value: "9223372036.854775807"},
+		},
+		{ // 5
+			Input: "9223372036.854775808",
+			Err:   &RangeError{Value: "9223372036.854775808"},
+		},
+		{ // 6
+			Input:  "9223372036.854774",
+			Output: 9223372036854774784,
+		},
+		{ // 7
+			Input:  "-9223372036.854775808",
+			Output: math.MinInt64,
+		},
+	} {
+		var output struct {
+			D time.Duration `unit:"s"`
+		}
+
+		err := ProcessValues(url.Values{"D": []string{test.Input}}, &output)
+		if test.Err == nil && err != nil {
+			t.Errorf("test %d: unexpected error: %s", n+1, err)
+		} else if test.Err != nil && !reflect.DeepEqual(err, ErrorMap{"D": test.Err}) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		} else if output.D != test.Output {
+			t.Errorf("test %d: expecting duration %d, got %d", n+1, test.Output, output.D)
+		}
+	}
+}
+
+func TestProcessDefaults(t *testing.T) {
+	type Inner struct {
+		E string `default:"inner"`
+	}
+
+	type defaults struct {
+		Inner
+		A int       `default:"5"`
+		B bool      `form:",required" default:"true"`
+		C []int     `default:"1,2,3"`
+		D time.Time `default:"2020-01-02"`
+		F Upper     `default:"abc"`
+		G *uint     `default:"7"`
+		H string    `default:"unused"`
+	}
+
+	var output defaults
+
+	if err := ProcessValues(url.Values{"H": []string{"set"}}, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	seven := uint(7)
+	expected := defaults{
+		Inner: Inner{E: "inner"},
+		A:     5,
+		B:     true,
+		C:     []int{1, 2, 3},
+		D:     time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		F:     "ABC",
+		G:     &seven,
+		H:     "set",
+	}
+
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("expecting output %#v, got %#v", expected, output)
+	}
+
+	for n, test := range [...]struct {
+		Input interface{}
+		Err   error
+	}{
+		{ // 1
+			&struct {
+				A int `default:"a"`
+			}{},
+			strconv.ErrSyntax,
+		},
+		{ // 2
+			&struct {
+				A uint8 `max:"10" default:"11"`
+			}{},
+			ErrNotInRange,
+		},
+		{ // 3
+			&struct {
+				Rows []struct {
+					A bool `default:"maybe"`
+				}
+			}{},
+			ErrInvalidBoolean,
+		},
+		{ // 4
+			&struct {
+				A map[string]int `default:"1"`
+			}{},
+			errors.ErrUnsupported,
+		},
+		{ // 5
+			&struct {
+				A struct {
+					B int
+				} `default:"1"`
+			}{},
+			errors.ErrUnsupported,
+		},
+		{ // 6
+			&struct {
+				X `default:"a"`
+			}{},
+			errors.ErrUnsupported,
+		},
+	} {
+		var te *TagError
+
+		if err := ProcessValues(url.Values{}, test.Input); !errors.As(err, &te) || te.Tag != "default" {
+			t.Errorf("test %d: expecting TagError, got %v", n+1, err)
+		} else if !errors.Is(err, test.Err) {
+			t.Errorf("test %d: expecting error %s, got %s", n+1, test.Err, err)
+		}
+	}
+}
+
+func TestProcessSources(t *testing.T) {
+	type sources struct {
+		Token   string   `form:"X-Token,header,required"`
+		Accept  []string `form:"Accept,header"`
+		Session string   `form:"session,cookie"`
+		ID      int      `form:"id,path,required"`
+		Query   string   `form:"q"`
+		Nested  struct {
+			Lang string `form:"Accept-Language"`
+			Page int    `form:"page,path"`
+		} `form:"nested,header"`
+	}
+
+	r := newRequest(url.Values{
+		"q":       []string{"search"},
+		"X-Token": []string{"from query"},
+	}, url.Values{})
+
+	r.Header.Set("X-Token", "abc")
+	r.Header.Add("Accept", "text/html")
+	r.Header.Add("Accept", "text/plain")
+	r.Header.Set("Nested.Accept-Language", "en")
+	r.Header.Set("Cookie", "session=123; other=456")
+	r.SetPathValue("id", "42")
+	r.SetPathValue("nested.page", "2")
+
+	var output sources
+
+	if err := Process(r, &output); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := sources{
+		Token:   "abc",
+		Accept:  []string{"text/html", "text/plain"},
+		Session: "123",
+		ID:      42,
+		Query:   "search",
+	}
+
+	expected.Nested.Lang = "en"
+	expected.Nested.Page = 2
+
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("expecting output %#v, got %#v", expected, output)
+	}
+
+	output = sources{}
+
+	err := ProcessValues(url.Values{
+		"X-Token": []string{"abc"},
+		"id":      []string{"1"},
+	}, &output)
+	if expectedErr := (ErrorMap{
+		"X-Token": ErrRequiredMissing,
+		"id":      ErrRequiredMissing,
+	}); !reflect.DeepEqual(err, expectedErr) {
+		t.Errorf("expecting error %v, got %v", expectedErr, err)
+	}
+}
+
+func TestProcessFiles(t *testing.T) {
+	png := "\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR"
+
+	type files struct {
+		Image  *multipart.FileHeader   `form:"image" accept:"image/*"`
+		Docs   []*multipart.FileHeader `form:"docs" maxfiles:"2" maxsize:"10"`
+		Avatar *multipart.FileHeader   `form:"avatar,required"`
+		Rows   []struct {
+			Photo *multipart.FileHeader `form:"photo" accept:"image/png"`
+		} `form:"rows"`
+	}
+
+	for n, test := range [...]struct {
+		Files map[string][]string
+		Check func(files) bool
+		Err   error
+	}{
+		{ // 1
+			Files: map[string][]string{
+				"image":         {png},
+				"docs":          {"hello", "world"},
+				"avatar":        {png},
+				"rows[1].photo": {png},
+			},
+			Check: func(f files) bool {
+				return f.Image != nil && f.Image.Filename == "image0" && len(f.Docs) == 2 && f.Docs[1].Filename == "docs1" && f.Avatar != nil && len(f.Rows) == 2 && f.Rows[0].Photo == nil && f.Rows[1].Photo != nil
+			},
+		},
+		{ // 2
+			Files: map[string][]string{
+				"image":         {"plain text"},
+				"docs":          {"hello", "hello, world", "beep"},
+				"rows[0].photo": {"GIF89a"},
+			},
+			Check: func(f files) bool {
+				return f.Image == nil && f.Docs == nil && f.Avatar == nil && f.Rows[0].Photo == nil
+			},
+			Err: ErrorMap{
+				"image":  ErrInvalidType,
+				"docs":   ErrTooManyFiles,
+				"avatar": ErrRequiredMissing,
+				"rows": Errors{
+					ErrorMap{
+						"photo": ErrInvalidType,
+					},
+				},
+			},
+		},
+		{ // 3
+			Files: map[string][]string{
+				"docs":   {"hello", "hello, world"},
+				"avatar": {""},
+			},
+			Check: func(f files) bool {
+				return f.Docs == nil && f.Avatar != nil
+			},
+			Err: ErrorMap{
+				"docs": Errors{
+					nil,
+					ErrFileTooLarge,
+				},
+			},
+		},
+	} {
+		var buf bytes.Buffer
+
+		w := multipart.NewWriter(&buf)
+
+		for key, contents := range test.Files {
+			for m, c := range contents {
+				fw, _ := w.CreateFormFile(key, fmt.Sprintf("%s%d", key, m))
+				io.WriteString(fw, c)
+			}
+		}
+
+		w.Close()
+
+		r := http.Request{
+			Method: http.MethodPost,
+			URL:    &url.URL{},
+			Header: http.Header{
+				"Content-Type": []string{w.FormDataContentType()},
+			},
+			Body: io.NopCloser(&buf),
+		}
+
+		var output files
+
+		err := Process(&r, &output)
+		if !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: expecting error: %v\ngot: %v", n+1, test.Err, err)
+		}
+
+		if !test.Check(output) {
+			t.Errorf("test %d: output check failed: %#v", n+1, output)
+		}
+	}
+}
+
+func TestProcessIn(t *testing.T) {
+	loc := time.FixedZone("TEST", 3600)
+	tag := time.FixedZone("TAG", -7200)
+
+	var output struct {
+		A time.Time
+		B time.Time `tz:"Etc/GMT+2"`
+	}
+
+	r := http.Request{
+		Method: http.MethodGet,
+		URL: &url.URL{
+			RawQuery: url.Values{
+				"A": []string{"2020-02-03T04:05"},
+				"B": []string{"2020-02-03T04:05"},
+			}.Encode(),
+		},
+	}
+
+	if err := ProcessIn(&r, &output, loc); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := time.Date(2020, 2, 3, 4, 5, 0, 0, loc); !output.A.Equal(expected) {
+		t.Errorf("expecting time %s, got %s", expected, output.A)
+	}
+
+	if expected := time.Date(2020, 2, 3, 4, 5, 0, 0, tag); !output.B.Equal(expected) {
+		t.Errorf("expecting time %s, got %s", expected, output.B)
+	}
+}
+
+type orderRecorder []string
+
+type ordered struct {
+	rec *orderRecorder
+}
+
+func (o *ordered) ParseForm(data []string) error {
+	*o.rec = append(*o.rec, data[0])
+
+	return errors.New(data[0])
+}
+
+type OrderedEmbed struct {
+	D ordered
+	E ordered
+}
+
+func TestProcessOrder(t *testing.T) {
+	vals := url.Values{}
+
+	for _, key := range [...]string{"Z", "A", "M", "Inner.Y", "Inner.B", "D", "E", "C"} {
+		vals.Set(key, key)
+	}
+
+	for n, test := range [...]struct {
+		Decoder  *Decoder
+		Expected []string
+	}{
+		{ // 1
+			Decoder:  NewDecoder(),
+			Expected: []string{"Z", "A", "M", "Inner.Y", "Inner.B", "D", "E", "C"},
+		},
+		{ // 2
+			Decoder:  NewDecoder(StopOnError()),
+			Expected: []string{"Z"},
+		},
+	} {
+		for i := 0; i < 20; i++ {
+			var (
+				rec    orderRecorder
+				output struct {
+					Z, A, M ordered
+					Inner   struct {
+						Y, B ordered
+					}
+					OrderedEmbed
+					C ordered
+				}
+			)
+
+			for _, o := range [...]*ordered{&output.Z, &output.A, &output.M, &output.Inner.Y, &output.Inner.B, &output.D, &output.E, &output.C} {
+				o.rec = &rec
+			}
+
+			err := test.Decoder.ProcessValues(vals, &output)
+			if !reflect.DeepEqual([]string(rec), test.Expected) {
+				t.Errorf("test %d: expecting fields to be processed in order %v, got %v", n+1, test.Expected, rec)
+
+				break
+			}
+
+			if em, ok := err.(ErrorMap); !ok || len(em) != len(test.Expected) {
+				t.Errorf("test %d: expecting %d errors, got %v", n+1, len(test.Expected), err)
+
+				break
+			}
+		}
+	}
+}
+
+func TestProcessInRange(t *testing.T) {
+	loc := time.FixedZone("TEST", -5*3600)
+
+	type ranged struct {
+		A time.Time `min:"2024-01-01" max:"2024-12-31"`
+		B time.Time `max:"2024-12-31" tz:"UTC"`
+	}
+
+	for n, test := range [...]struct {
+		Query url.Values
+		Err   error
+	}{
+		{ // 1
+			Query: url.Values{
+				"A": []string{"2024-12-31"},
+				"B": []string{"2024-12-31"},
+			},
+		},
+		{ // 2
+			Query: url.Values{
+				"A": []string{"2024-01-01"},
+			},
+		},
+		{ // 3
+			Query: url.Values{
+				"A": []string{"2023-12-31T23:59"},
+			},
+			Err: ErrorMap{
+				"A": &RangeError{
+					Min:   time.Date(2024, 1, 1, 0, 0, 0, 0, loc),
+					Max:   time.Date(2024, 12, 31, 0, 0, 0, 0, loc),
+					Value: time.Date(2023, 12, 31, 23, 59, 0, 0, loc),
+				},
+			},
+		},
+		{ // 4
+			Query: url.Values{
+				"B": []string{"2024-12-31T00:01"},
+			},
+			Err: ErrorMap{
+				"B": &RangeError{
+					Max:   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+					Value: time.Date(2024, 12, 31, 0, 1, 0, 0, time.UTC),
+				},
+			},
+		},
+	} {
+		var output ranged
+
+		if err := ProcessIn(newRequest(test.Query, url.Values{}), &output, loc); !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		}
+	}
+
+	var output ranged
+
+	if err := NewDecoder(Location(loc)).ProcessValues(url.Values{"A": []string{"2024-12-31"}}, &output); err != nil {
+		t.Errorf("unexpected error: %s", err)
 	}
 }
@@ -0,0 +1,177 @@
+package form
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The functions in this file are used by the methods generated by the formgen
+// command, and by the processors used by Process, so that both perform the
+// same parsing and checks, and return the same errors.
+
+// ParseInt parses a value for a signed integer field of the given bit size,
+// returning a *ParseError if it cannot be parsed and a *RangeError if it is
+// not between min and max, inclusive.
+func ParseInt(data string, bits int, min, max int64) (int64, error) {
+	num, err := strconv.ParseInt(data, 10, bits)
+	if err != nil {
+		return 0, &ParseError{Value: data, Err: err}
+	}
+
+	if num < min || num > max {
+		re := &RangeError{Value: num}
+
+		if min != math.MinInt64 {
+			re.Min = min
+		}
+
+		if max != math.MaxInt64 {
+			re.Max = max
+		}
+
+		return 0, re
+	}
+
+	return num, nil
+}
+
+// ParseUint parses a value for an unsigned integer field of the given bit
+// size, returning a *ParseError if it cannot be parsed and a *RangeError if it
+// is not between min and max, inclusive.
+func ParseUint(data string, bits int, min, max uint64) (uint64, error) {
+	num, err := strconv.ParseUint(data, 10, bits)
+	if err != nil {
+		return 0, &ParseError{Value: data, Err: err}
+	}
+
+	if num < min || num > max {
+		re := &RangeError{Value: num}
+
+		if min != 0 {
+			re.Min = min
+		}
+
+		if max != math.MaxUint64 {
+			re.Max = max
+		}
+
+		return 0, re
+	}
+
+	return num, nil
+}
+
+// ParseFloat parses a value for a floating point field of the given bit size,
+// returning a *ParseError if it cannot be parsed and a *RangeError if it is
+// not between min and max, inclusive.
+func ParseFloat(data string, bits int, min, max float64) (float64, error) {
+	num, err := strconv.ParseFloat(data, bits)
+	if err != nil {
+		return 0, &ParseError{Value: data, Err: err}
+	}
+
+	if num < min || num > max {
+		re := &RangeError{Value: num}
+
+		if min != -math.MaxFloat64 {
+			re.Min = min
+		}
+
+		if max != math.MaxFloat64 {
+			re.Max = max
+		}
+
+		return 0, re
+	}
+
+	return num, nil
+}
+
+// ParseBool parses a value for a boolean field, using the default words for
+// true and false, returning a *ParseError if the value is not one of them.
+func ParseBool(data string) (bool, error) {
+	return parseBool(data, trues[:], falses[:])
+}
+
+func parseBool(data string, ts, fs [][]byte) (bool, error) {
+	for _, b := range ts {
+		if matchString(data, b) {
+			return true, nil
+		}
+	}
+
+	for _, b := range fs {
+		if matchString(data, b) {
+			return false, nil
+		}
+	}
+
+	return false, &ParseError{Value: data, Err: ErrInvalidBoolean}
+}
+
+// CheckString checks a value for a string field, returning a *LengthError if
+// its length, in runes, is outside of minLen and maxLen, with a maxLen of zero
+// meaning no maximum, and a *PatternError if it does not match the regular
+// expression, when not nil.
+func CheckString(data string, minLen, maxLen int, regex *regexp.Regexp) error {
+	if minLen > 0 || maxLen > 0 {
+		l := utf8.RuneCountInString(data)
+
+		if l < minLen || maxLen > 0 && l > maxLen {
+			return &LengthError{Min: minLen, Max: maxLen, Len: l}
+		}
+	}
+
+	if regex != nil && !regex.MatchString(data) {
+		return &PatternError{Pattern: regex.String(), Value: data}
+	}
+
+	return nil
+}
+
+// CheckCount checks the number of values for a slice field, returning a
+// *CountError if it is outside of minCount and maxCount, with a maxCount of
+// zero meaning no maximum.
+func CheckCount(count, minCount, maxCount int) error {
+	if count < minCount || maxCount > 0 && count > maxCount {
+		return &CountError{Min: minCount, Max: maxCount, Count: count}
+	}
+
+	return nil
+}
+
+// CheckChoice checks that a parsed value is one of the given values, returning
+// a *ChoiceError, holding the unparsed data and the choices as set in the
+// 'oneof' tag, if it is not.
+func CheckChoice[T comparable](v T, data string, choices []string, values ...T) error {
+	for _, c := range values {
+		if c == v {
+			return nil
+		}
+	}
+
+	return &ChoiceError{
+		Value:   data,
+		Choices: choices,
+	}
+}
+
+// AddError adds the error for the given key to the ErrorMap, creating it if
+// nil, and sets the key of any *ParseError that does not yet have one.
+func AddError(errs ErrorMap, key string, err error) ErrorMap {
+	walkParseErrors(err, func(pe *ParseError) {
+		if pe.Key == "" {
+			pe.Key = key
+		}
+	})
+
+	if errs == nil {
+		errs = make(ErrorMap)
+	}
+
+	errs[key] = err
+
+	return errs
+}
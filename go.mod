@@ -1,3 +1,3 @@
 module vimagination.zapto.org/form
 
-go 1.16
+go 1.22
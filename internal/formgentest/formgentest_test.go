@@ -0,0 +1,267 @@
+package formgentest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"vimagination.zapto.org/form"
+)
+
+// reflective has an option set, so never uses the generated methods.
+var reflective = form.NewDecoder(form.TagName("form"))
+
+type generated interface {
+	ParseFormRequest(*http.Request) error
+	EncodeForm() url.Values
+}
+
+type request struct {
+	Query, Post url.Values
+	Header      http.Header
+	Cookies     []*http.Cookie
+	Path        map[string]string
+}
+
+func (r request) build() *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/?"+r.Query.Encode(), strings.NewReader(r.Post.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	for key, vals := range r.Header {
+		for _, val := range vals {
+			req.Header.Add(key, val)
+		}
+	}
+
+	for _, c := range r.Cookies {
+		req.AddCookie(c)
+	}
+
+	for key, val := range r.Path {
+		req.SetPathValue(key, val)
+	}
+
+	return req
+}
+
+func TestConformance(t *testing.T) {
+	for n, test := range [...]struct {
+		New     func() generated
+		Request request
+	}{
+		{ // 1
+			New: func() generated { return new(Basic) },
+		},
+		{ // 2
+			New: func() generated { return new(Basic) },
+			Request: request{
+				Query: url.Values{
+					"int":     []string{"-5"},
+					"int8":    []string{"127"},
+					"int16":   []string{"-300"},
+					"int32":   []string{"010"},
+					"int64":   []string{"9223372036854775807"},
+					"uint":    []string{"10"},
+					"uint8":   []string{"255"},
+					"uint16":  []string{"0"},
+					"uint32":  []string{"4294967295"},
+					"uint64":  []string{"5"},
+					"float32": []string{"0.75"},
+					"float64": []string{"2500"},
+					"string":  []string{"abc"},
+					"choice":  []string{"green"},
+					"bool":    []string{"Yes"},
+					"level":   []string{"-3"},
+					"flag":    []string{"off"},
+					"ptr":     []string{"0"},
+					"strptr":  []string{"abc"},
+					"NoName":  []string{"x"},
+					"Skip":    []string{"y"},
+					"private": []string{"z"},
+				},
+			},
+		},
+		{ // 3
+			New: func() generated { return new(Basic) },
+			Request: request{
+				Post: url.Values{
+					"int":     []string{"101"},
+					"int8":    []string{"200"},
+					"int16":   []string{"x"},
+					"int32":   []string{"3"},
+					"uint":    []string{"-1"},
+					"uint8":   []string{"0"},
+					"uint64":  []string{"4"},
+					"float32": []string{"2"},
+					"float64": []string{"0.2"},
+					"string":  []string{"ABC"},
+					"choice":  []string{"pink"},
+					"bool":    []string{"maybe"},
+					"level":   []string{"4"},
+					"flag":    []string{"x"},
+					"ptr":     []string{"-1"},
+					"strptr":  []string{"abcd"},
+				},
+			},
+		},
+		{ // 4
+			New: func() generated { return new(Basic) },
+			Request: request{
+				Query: url.Values{
+					"int64":  []string{"1"},
+					"choice": []string{"red"},
+					"string": []string{"a"},
+				},
+				Post: url.Values{
+					"string": []string{"abcdef"},
+				},
+			},
+		},
+		{ // 5
+			New: func() generated { return new(Lists) },
+		},
+		{ // 6
+			New: func() generated {
+				return &Lists{Ints: make([]int, 1, 3), Ptrs: []*uint8{new(uint8), new(uint8), new(uint8)}}
+			},
+			Request: request{
+				Query: url.Values{
+					"ints":     []string{"1", "2"},
+					"strings":  []string{"a"},
+					"ptrs":     []string{"1", "x", "256"},
+					"floats":   []string{"1", "4", "x"},
+					"flags":    []string{"true", "no", "?"},
+					"inner.b":  []string{"c"},
+					"inner2.a": []string{"1"},
+					"inner2.b": []string{"d"},
+					"a":        []string{"2"},
+					"b":        []string{"e"},
+				},
+			},
+		},
+		{ // 7
+			New: func() generated { return new(Lists) },
+			Request: request{
+				Query: url.Values{
+					"ints": []string{"1", "2"},
+					"b":    []string{""},
+				},
+				Post: url.Values{
+					"ints":     []string{"10", "11"},
+					"inner.a":  []string{"x"},
+					"inner.b":  []string{""},
+					"inner2.a": []string{"y"},
+					"inner2.b": []string{"f"},
+				},
+			},
+		},
+		{ // 8
+			New: func() generated { return new(Sources) },
+		},
+		{ // 9
+			New: func() generated { return new(Sources) },
+			Request: request{
+				Query: url.Values{
+					"name":        []string{"q"},
+					"post":        []string{"q"},
+					"query":       []string{"1", "2"},
+					"get":         []string{"q"},
+					"value":       []string{"3"},
+					"named.name":  []string{"n"},
+					"named.value": []string{"x"},
+				},
+				Post: url.Values{
+					"name":        []string{"p"},
+					"post":        []string{"p"},
+					"query":       []string{"3"},
+					"get":         []string{"p"},
+					"named.value": []string{"4"},
+				},
+				Header: http.Header{
+					"X-Header": []string{"h1", "h2"},
+				},
+				Cookies: []*http.Cookie{
+					{Name: "cookie", Value: "1"},
+					{Name: "cookie", Value: "x"},
+					{Name: "other", Value: "2"},
+				},
+				Path: map[string]string{
+					"id": "12",
+				},
+			},
+		},
+		{ // 10
+			New: func() generated { return new(Sources) },
+			Request: request{
+				Post: url.Values{
+					"get": []string{"p"},
+				},
+				Path: map[string]string{
+					"id": "-1",
+				},
+			},
+		},
+		{ // 11
+			New: func() generated { return new(Parsers) },
+		},
+		{ // 12
+			New: func() generated { return &Parsers{Words: make(Words, 0, 5)} },
+			Request: request{
+				Query: url.Values{
+					"upper":  []string{"abc", "def"},
+					"words":  []string{"a", "b c"},
+					"uppers": []string{"x", "y"},
+					"query":  []string{"d e"},
+				},
+			},
+		},
+		{ // 13
+			New: func() generated { return new(Parsers) },
+			Request: request{
+				Query: url.Values{
+					"upper":  []string{""},
+					"uppers": []string{"x", "", "z"},
+					"query":  []string{" "},
+				},
+				Post: url.Values{
+					"words":  []string{""},
+					"uppers": []string{"", "y"},
+				},
+			},
+		},
+	} {
+		gen, ref := test.New(), test.New()
+
+		genErr := form.Process(test.Request.build(), gen)
+		refErr := reflective.Process(test.Request.build(), ref)
+
+		if !reflect.DeepEqual(genErr, refErr) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, refErr, genErr)
+		}
+
+		if !reflect.DeepEqual(gen, ref) {
+			t.Errorf("test %d: expecting output %#v, got %#v", n+1, ref, gen)
+		}
+
+		genVals, err := form.Encode(gen)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		refVals, err := reflective.Encode(ref)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %s", n+1, err)
+		}
+
+		if !reflect.DeepEqual(genVals, refVals) {
+			t.Errorf("test %d: expecting encoded values %v, got %v", n+1, refVals, genVals)
+		}
+
+		if vals := gen.EncodeForm(); !reflect.DeepEqual(vals, genVals) {
+			t.Errorf("test %d: expecting form.Encode to use EncodeForm, got %v", n+1, vals)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package formgentest contains types with methods generated by formgen, which
+// are used to check that the generated methods match the form package.
+package formgentest
+
+import (
+	"errors"
+	"strings"
+)
+
+//go:generate go run ../../cmd/formgen -type Basic,Lists,Sources,Parsers -output types_form.go
+
+var errEmpty = errors.New("empty value")
+
+type Level int8
+
+type Flag bool
+
+type Basic struct {
+	Int     int     `form:"int" min:"-5" max:"100"`
+	Int8    int8    `form:"int8"`
+	Int16   int16   `form:"int16,omitempty"`
+	Int32   int32   `form:"int32" oneof:"1 2 010"`
+	Int64   int64   `form:"int64,required"`
+	Uint    uint    `form:"uint" max:"10"`
+	Uint8   uint8   `form:"uint8,omitempty" min:"1"`
+	Uint16  uint16  `form:"uint16" default:"7"`
+	Uint32  uint32  `form:"uint32"`
+	Uint64  uint64  `form:"uint64" oneof:"3 5"`
+	Float32 float32 `form:"float32" min:"0.5" max:"1.5"`
+	Float64 float64 `form:"float64,omitempty" oneof:"0.1 2.5e3"`
+	String  string  `form:"string" regex:"^[a-z]+$" minlen:"2" maxlen:"5"`
+	Choice  string  `form:"choice,required" oneof:"red green blue"`
+	Bool    bool    `form:"bool"`
+	Level   Level   `form:"level" max:"3" default:"1"`
+	Flag    Flag    `form:"flag,omitempty"`
+	Ptr     *int    `form:"ptr" min:"0"`
+	StrPtr  *string `form:"strptr" maxlen:"3"`
+	NoName  string
+	Skip    string `form:"-"`
+	private string
+}
+
+type Inner struct {
+	A int    `form:"a"`
+	B string `form:"b,required"`
+}
+
+type Lists struct {
+	Ints    []int     `form:"ints" max:"9" maxcount:"3"`
+	Strings []string  `form:"strings" mincount:"1" default:"x,y"`
+	Ptrs    []*uint8  `form:"ptrs"`
+	Floats  []float64 `form:"floats" oneof:"1 2 3"`
+	Flags   []Flag    `form:"flags"`
+	In      Inner     `form:"inner"`
+	Inner2  Inner     `form:"inner2,post"`
+	Inner
+}
+
+type Embedded struct {
+	Name  string `form:"name"`
+	Value int    `form:"value"`
+}
+
+type Sources struct {
+	Name   string  `form:"name"`
+	Post   string  `form:"post,post"`
+	Query  []int   `form:"query,query"`
+	Get    string  `form:"get,get,required"`
+	Header string  `form:"X-Header,header"`
+	Cookie []Level `form:"cookie,cookie"`
+	Path   uint    `form:"id,path"`
+	Embedded
+	Named Embedded `form:"named,query"`
+}
+
+// Upper is a string that is parsed in upper case.
+type Upper string
+
+func (u *Upper) ParseForm(data []string) error {
+	if data[0] == "" {
+		return errEmpty
+	}
+
+	*u = Upper(strings.ToUpper(data[0]))
+
+	return nil
+}
+
+// Words is a list of the space separated words from all of the values.
+type Words []string
+
+func (w *Words) ParseForm(data []string) error {
+	*w = (*w)[:0]
+
+	for _, d := range data {
+		*w = append(*w, strings.Fields(d)...)
+	}
+
+	if len(*w) == 0 {
+		return errEmpty
+	}
+
+	return nil
+}
+
+func (w Words) FormatForm() []string {
+	return []string{strings.Join(w, " ")}
+}
+
+type Parsers struct {
+	Upper  Upper   `form:"upper,required"`
+	Words  Words   `form:"words,omitempty" default:"a b,c"`
+	Uppers []Upper `form:"uppers" maxcount:"2"`
+	Query  Words   `form:"query,query"`
+}
@@ -0,0 +1,913 @@
+// Code generated by formgen. DO NOT EDIT.
+
+package formgentest
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+
+	"vimagination.zapto.org/form"
+)
+
+// ParseFormRequest parses the form data from the request into the Basic, in the
+// same way as form.Process.
+func (s *Basic) ParseFormRequest(r *http.Request) error {
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+
+	var (
+		errs form.ErrorMap
+		val  []string
+		ok   bool
+	)
+
+	val, ok = r.Form["int"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, -5, 100); err != nil {
+			errs = form.AddError(errs, "int", err)
+		} else {
+			s.Int = int(x)
+		}
+	}
+
+	val, ok = r.Form["int8"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], 8, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "int8", err)
+		} else {
+			s.Int8 = int8(x)
+		}
+	}
+
+	val, ok = r.Form["int16"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], 16, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "int16", err)
+		} else {
+			s.Int16 = int16(x)
+		}
+	}
+
+	val, ok = r.Form["int32"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], 32, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "int32", err)
+		} else if err := form.CheckChoice(x, val[0], []string{"1", "2", "010"}, 1, 2, 10); err != nil {
+			errs = form.AddError(errs, "int32", err)
+		} else {
+			s.Int32 = int32(x)
+		}
+	}
+
+	val, ok = r.Form["int64"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], 64, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "int64", err)
+		} else {
+			s.Int64 = x
+		}
+	} else {
+		errs = form.AddError(errs, "int64", form.ErrRequiredMissing)
+	}
+
+	val, ok = r.Form["uint"]
+
+	if ok {
+		if x, err := form.ParseUint(val[0], strconv.IntSize, 0, 10); err != nil {
+			errs = form.AddError(errs, "uint", err)
+		} else {
+			s.Uint = uint(x)
+		}
+	}
+
+	val, ok = r.Form["uint8"]
+
+	if ok {
+		if x, err := form.ParseUint(val[0], 8, 1, math.MaxUint64); err != nil {
+			errs = form.AddError(errs, "uint8", err)
+		} else {
+			s.Uint8 = uint8(x)
+		}
+	}
+
+	val, ok = r.Form["uint16"]
+
+	if !ok {
+		val, ok = []string{"7"}, true
+	}
+
+	if ok {
+		if x, err := form.ParseUint(val[0], 16, 0, math.MaxUint64); err != nil {
+			errs = form.AddError(errs, "uint16", err)
+		} else {
+			s.Uint16 = uint16(x)
+		}
+	}
+
+	val, ok = r.Form["uint32"]
+
+	if ok {
+		if x, err := form.ParseUint(val[0], 32, 0, math.MaxUint64); err != nil {
+			errs = form.AddError(errs, "uint32", err)
+		} else {
+			s.Uint32 = uint32(x)
+		}
+	}
+
+	val, ok = r.Form["uint64"]
+
+	if ok {
+		if x, err := form.ParseUint(val[0], 64, 0, math.MaxUint64); err != nil {
+			errs = form.AddError(errs, "uint64", err)
+		} else if err := form.CheckChoice(x, val[0], []string{"3", "5"}, 3, 5); err != nil {
+			errs = form.AddError(errs, "uint64", err)
+		} else {
+			s.Uint64 = x
+		}
+	}
+
+	val, ok = r.Form["float32"]
+
+	if ok {
+		if x, err := form.ParseFloat(val[0], 32, 0.5, 1.5); err != nil {
+			errs = form.AddError(errs, "float32", err)
+		} else {
+			s.Float32 = float32(x)
+		}
+	}
+
+	val, ok = r.Form["float64"]
+
+	if ok {
+		if x, err := form.ParseFloat(val[0], 64, -math.MaxFloat64, math.MaxFloat64); err != nil {
+			errs = form.AddError(errs, "float64", err)
+		} else if err := form.CheckChoice(x, val[0], []string{"0.1", "2.5e3"}, 0.1, 2500); err != nil {
+			errs = form.AddError(errs, "float64", err)
+		} else {
+			s.Float64 = x
+		}
+	}
+
+	val, ok = r.Form["string"]
+
+	if ok {
+		if err := form.CheckString(val[0], 2, 5, regexBasic0); err != nil {
+			errs = form.AddError(errs, "string", err)
+		} else {
+			s.String = val[0]
+		}
+	}
+
+	val, ok = r.Form["choice"]
+
+	if ok {
+		if err := form.CheckChoice(val[0], val[0], []string{"red", "green", "blue"}, "red", "green", "blue"); err != nil {
+			errs = form.AddError(errs, "choice", err)
+		} else {
+			s.Choice = val[0]
+		}
+	} else {
+		errs = form.AddError(errs, "choice", form.ErrRequiredMissing)
+	}
+
+	val, ok = r.Form["bool"]
+
+	if ok {
+		if x, err := form.ParseBool(val[0]); err != nil {
+			errs = form.AddError(errs, "bool", err)
+		} else {
+			s.Bool = x
+		}
+	}
+
+	val, ok = r.Form["level"]
+
+	if !ok {
+		val, ok = []string{"1"}, true
+	}
+
+	if ok {
+		if x, err := form.ParseInt(val[0], 8, math.MinInt64, 3); err != nil {
+			errs = form.AddError(errs, "level", err)
+		} else {
+			s.Level = Level(x)
+		}
+	}
+
+	val, ok = r.Form["flag"]
+
+	if ok {
+		if x, err := form.ParseBool(val[0]); err != nil {
+			errs = form.AddError(errs, "flag", err)
+		} else {
+			s.Flag = Flag(x)
+		}
+	}
+
+	val, ok = r.Form["ptr"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, 0, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "ptr", err)
+		} else {
+			p := int(x)
+			s.Ptr = &p
+		}
+	}
+
+	val, ok = r.Form["strptr"]
+
+	if ok {
+		if err := form.CheckString(val[0], 0, 3, nil); err != nil {
+			errs = form.AddError(errs, "strptr", err)
+		} else {
+			p := val[0]
+			s.StrPtr = &p
+		}
+	}
+
+	val, ok = r.Form["NoName"]
+
+	if ok {
+		s.NoName = val[0]
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// EncodeForm encodes the Basic into form values, in the same way as
+// form.Encode.
+func (s Basic) EncodeForm() url.Values {
+	vals := make(url.Values)
+
+	vals["int"] = []string{strconv.FormatInt(int64(s.Int), 10)}
+
+	vals["int8"] = []string{strconv.FormatInt(int64(s.Int8), 10)}
+
+	if s.Int16 != 0 {
+		vals["int16"] = []string{strconv.FormatInt(int64(s.Int16), 10)}
+	}
+
+	vals["int32"] = []string{strconv.FormatInt(int64(s.Int32), 10)}
+
+	vals["int64"] = []string{strconv.FormatInt(s.Int64, 10)}
+
+	vals["uint"] = []string{strconv.FormatUint(uint64(s.Uint), 10)}
+
+	if s.Uint8 != 0 {
+		vals["uint8"] = []string{strconv.FormatUint(uint64(s.Uint8), 10)}
+	}
+
+	vals["uint16"] = []string{strconv.FormatUint(uint64(s.Uint16), 10)}
+
+	vals["uint32"] = []string{strconv.FormatUint(uint64(s.Uint32), 10)}
+
+	vals["uint64"] = []string{strconv.FormatUint(s.Uint64, 10)}
+
+	vals["float32"] = []string{strconv.FormatFloat(float64(s.Float32), 'g', -1, 32)}
+
+	if math.Float64bits(float64(s.Float64)) != 0 {
+		vals["float64"] = []string{strconv.FormatFloat(s.Float64, 'g', -1, 64)}
+	}
+
+	vals["string"] = []string{s.String}
+
+	vals["choice"] = []string{s.Choice}
+
+	vals["bool"] = []string{strconv.FormatBool(s.Bool)}
+
+	vals["level"] = []string{strconv.FormatInt(int64(s.Level), 10)}
+
+	if s.Flag {
+		vals["flag"] = []string{strconv.FormatBool(bool(s.Flag))}
+	}
+
+	if s.Ptr != nil {
+		vals["ptr"] = []string{strconv.FormatInt(int64(*s.Ptr), 10)}
+	}
+
+	if s.StrPtr != nil {
+		vals["strptr"] = []string{*s.StrPtr}
+	}
+
+	vals["NoName"] = []string{s.NoName}
+
+	return vals
+}
+
+var (
+	regexBasic0 = regexp.MustCompile("^[a-z]+$")
+)
+
+// ParseFormRequest parses the form data from the request into the Lists, in the
+// same way as form.Process.
+func (s *Lists) ParseFormRequest(r *http.Request) error {
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+
+	var (
+		errs form.ErrorMap
+		val  []string
+		ok   bool
+	)
+
+	val, ok = r.Form["ints"]
+
+	if ok {
+		if err := form.CheckCount(len(val), 0, 3); err != nil {
+			errs = form.AddError(errs, "ints", err)
+		} else {
+			if cap(s.Ints) >= len(val) {
+				s.Ints = s.Ints[:len(val)]
+			} else {
+				s.Ints = make([]int, len(val))
+			}
+
+			var es form.Errors
+
+			for n, v := range val {
+				if x, err := form.ParseInt(v, strconv.IntSize, math.MinInt64, 9); err != nil {
+					if es == nil {
+						es = make(form.Errors, len(val))
+					}
+
+					es[n] = err
+				} else {
+					s.Ints[n] = int(x)
+				}
+			}
+
+			if es != nil {
+				errs = form.AddError(errs, "ints", es)
+			}
+		}
+	}
+
+	val, ok = r.Form["strings"]
+
+	if !ok {
+		val, ok = []string{"x", "y"}, true
+	}
+
+	if ok {
+		if err := form.CheckCount(len(val), 1, 0); err != nil {
+			errs = form.AddError(errs, "strings", err)
+		} else {
+			if cap(s.Strings) >= len(val) {
+				s.Strings = s.Strings[:len(val)]
+			} else {
+				s.Strings = make([]string, len(val))
+			}
+
+			var es form.Errors
+
+			for n, v := range val {
+				s.Strings[n] = v
+			}
+
+			if es != nil {
+				errs = form.AddError(errs, "strings", es)
+			}
+		}
+	}
+
+	val, ok = r.Form["ptrs"]
+
+	if ok {
+		if cap(s.Ptrs) >= len(val) {
+			s.Ptrs = s.Ptrs[:len(val)]
+		} else {
+			s.Ptrs = make([]*uint8, len(val))
+		}
+
+		var es form.Errors
+
+		for n, v := range val {
+			if x, err := form.ParseUint(v, 8, 0, math.MaxUint64); err != nil {
+				if es == nil {
+					es = make(form.Errors, len(val))
+				}
+
+				es[n] = err
+			} else {
+				p := uint8(x)
+				s.Ptrs[n] = &p
+			}
+		}
+
+		if es != nil {
+			errs = form.AddError(errs, "ptrs", es)
+		}
+	}
+
+	val, ok = r.Form["floats"]
+
+	if ok {
+		if cap(s.Floats) >= len(val) {
+			s.Floats = s.Floats[:len(val)]
+		} else {
+			s.Floats = make([]float64, len(val))
+		}
+
+		var es form.Errors
+
+		for n, v := range val {
+			if x, err := form.ParseFloat(v, 64, -math.MaxFloat64, math.MaxFloat64); err != nil {
+				if es == nil {
+					es = make(form.Errors, len(val))
+				}
+
+				es[n] = err
+			} else if err := form.CheckChoice(x, v, []string{"1", "2", "3"}, 1, 2, 3); err != nil {
+				if es == nil {
+					es = make(form.Errors, len(val))
+				}
+
+				es[n] = err
+			} else {
+				s.Floats[n] = x
+			}
+		}
+
+		if es != nil {
+			errs = form.AddError(errs, "floats", es)
+		}
+	}
+
+	val, ok = r.Form["flags"]
+
+	if ok {
+		if cap(s.Flags) >= len(val) {
+			s.Flags = s.Flags[:len(val)]
+		} else {
+			s.Flags = make([]Flag, len(val))
+		}
+
+		var es form.Errors
+
+		for n, v := range val {
+			if x, err := form.ParseBool(v); err != nil {
+				if es == nil {
+					es = make(form.Errors, len(val))
+				}
+
+				es[n] = err
+			} else {
+				s.Flags[n] = Flag(x)
+			}
+		}
+
+		if es != nil {
+			errs = form.AddError(errs, "flags", es)
+		}
+	}
+
+	val, ok = r.Form["inner.a"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "inner.a", err)
+		} else {
+			s.In.A = int(x)
+		}
+	}
+
+	val, ok = r.Form["inner.b"]
+
+	if ok {
+		s.In.B = val[0]
+	} else {
+		errs = form.AddError(errs, "inner.b", form.ErrRequiredMissing)
+	}
+
+	val, ok = r.PostForm["inner2.a"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "inner2.a", err)
+		} else {
+			s.Inner2.A = int(x)
+		}
+	}
+
+	val, ok = r.PostForm["inner2.b"]
+
+	if ok {
+		s.Inner2.B = val[0]
+	} else {
+		errs = form.AddError(errs, "inner2.b", form.ErrRequiredMissing)
+	}
+
+	val, ok = r.Form["a"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "a", err)
+		} else {
+			s.Inner.A = int(x)
+		}
+	}
+
+	val, ok = r.Form["b"]
+
+	if ok {
+		s.Inner.B = val[0]
+	} else {
+		errs = form.AddError(errs, "b", form.ErrRequiredMissing)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// EncodeForm encodes the Lists into form values, in the same way as
+// form.Encode.
+func (s Lists) EncodeForm() url.Values {
+	vals := make(url.Values)
+
+	if len(s.Ints) > 0 {
+		data := make([]string, len(s.Ints))
+
+		for n, e := range s.Ints {
+			data[n] = strconv.FormatInt(int64(e), 10)
+		}
+
+		vals["ints"] = data
+	}
+
+	if len(s.Strings) > 0 {
+		data := make([]string, len(s.Strings))
+
+		for n, e := range s.Strings {
+			data[n] = e
+		}
+
+		vals["strings"] = data
+	}
+
+	if len(s.Ptrs) > 0 {
+		var data []string
+
+		for _, e := range s.Ptrs {
+			if e != nil {
+				data = append(data, strconv.FormatUint(uint64(*e), 10))
+			}
+		}
+
+		if len(data) > 0 {
+			vals["ptrs"] = data
+		}
+	}
+
+	if len(s.Floats) > 0 {
+		data := make([]string, len(s.Floats))
+
+		for n, e := range s.Floats {
+			data[n] = strconv.FormatFloat(e, 'g', -1, 64)
+		}
+
+		vals["floats"] = data
+	}
+
+	if len(s.Flags) > 0 {
+		data := make([]string, len(s.Flags))
+
+		for n, e := range s.Flags {
+			data[n] = strconv.FormatBool(bool(e))
+		}
+
+		vals["flags"] = data
+	}
+
+	vals["inner.a"] = []string{strconv.FormatInt(int64(s.In.A), 10)}
+
+	vals["inner.b"] = []string{s.In.B}
+
+	vals["inner2.a"] = []string{strconv.FormatInt(int64(s.Inner2.A), 10)}
+
+	vals["inner2.b"] = []string{s.Inner2.B}
+
+	vals["a"] = []string{strconv.FormatInt(int64(s.Inner.A), 10)}
+
+	vals["b"] = []string{s.Inner.B}
+
+	return vals
+}
+
+// ParseFormRequest parses the form data from the request into the Sources, in the
+// same way as form.Process.
+func (s *Sources) ParseFormRequest(r *http.Request) error {
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+
+	var (
+		errs  form.ErrorMap
+		query url.Values
+		val   []string
+		ok    bool
+	)
+
+	if r.URL != nil {
+		query = r.URL.Query()
+	}
+
+	val, ok = r.Form["name"]
+
+	if ok {
+		s.Name = val[0]
+	}
+
+	val, ok = r.PostForm["post"]
+
+	if ok {
+		s.Post = val[0]
+	}
+
+	val, ok = query["query"]
+
+	if ok {
+		if cap(s.Query) >= len(val) {
+			s.Query = s.Query[:len(val)]
+		} else {
+			s.Query = make([]int, len(val))
+		}
+
+		var es form.Errors
+
+		for n, v := range val {
+			if x, err := form.ParseInt(v, strconv.IntSize, math.MinInt64, math.MaxInt64); err != nil {
+				if es == nil {
+					es = make(form.Errors, len(val))
+				}
+
+				es[n] = err
+			} else {
+				s.Query[n] = int(x)
+			}
+		}
+
+		if es != nil {
+			errs = form.AddError(errs, "query", es)
+		}
+	}
+
+	val, ok = query["get"]
+
+	if ok {
+		s.Get = val[0]
+	} else {
+		errs = form.AddError(errs, "get", form.ErrRequiredMissing)
+	}
+
+	val = r.Header.Values("X-Header")
+	ok = len(val) > 0
+
+	if ok {
+		s.Header = val[0]
+	}
+
+	val = nil
+
+	for _, c := range r.Cookies() {
+		if c.Name == "cookie" {
+			val = append(val, c.Value)
+		}
+	}
+
+	ok = len(val) > 0
+
+	if ok {
+		if cap(s.Cookie) >= len(val) {
+			s.Cookie = s.Cookie[:len(val)]
+		} else {
+			s.Cookie = make([]Level, len(val))
+		}
+
+		var es form.Errors
+
+		for n, v := range val {
+			if x, err := form.ParseInt(v, 8, math.MinInt64, math.MaxInt64); err != nil {
+				if es == nil {
+					es = make(form.Errors, len(val))
+				}
+
+				es[n] = err
+			} else {
+				s.Cookie[n] = Level(x)
+			}
+		}
+
+		if es != nil {
+			errs = form.AddError(errs, "cookie", es)
+		}
+	}
+
+	if pv := r.PathValue("id"); pv != "" {
+		val, ok = []string{pv}, true
+	} else {
+		val, ok = nil, false
+	}
+
+	if ok {
+		if x, err := form.ParseUint(val[0], strconv.IntSize, 0, math.MaxUint64); err != nil {
+			errs = form.AddError(errs, "id", err)
+		} else {
+			s.Path = uint(x)
+		}
+	}
+
+	val, ok = r.Form["value"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "value", err)
+		} else {
+			s.Embedded.Value = int(x)
+		}
+	}
+
+	val, ok = query["named.name"]
+
+	if ok {
+		s.Named.Name = val[0]
+	}
+
+	val, ok = query["named.value"]
+
+	if ok {
+		if x, err := form.ParseInt(val[0], strconv.IntSize, math.MinInt64, math.MaxInt64); err != nil {
+			errs = form.AddError(errs, "named.value", err)
+		} else {
+			s.Named.Value = int(x)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// EncodeForm encodes the Sources into form values, in the same way as
+// form.Encode.
+func (s Sources) EncodeForm() url.Values {
+	vals := make(url.Values)
+
+	vals["name"] = []string{s.Name}
+
+	vals["post"] = []string{s.Post}
+
+	if len(s.Query) > 0 {
+		data := make([]string, len(s.Query))
+
+		for n, e := range s.Query {
+			data[n] = strconv.FormatInt(int64(e), 10)
+		}
+
+		vals["query"] = data
+	}
+
+	vals["get"] = []string{s.Get}
+
+	vals["value"] = []string{strconv.FormatInt(int64(s.Embedded.Value), 10)}
+
+	vals["named.name"] = []string{s.Named.Name}
+
+	vals["named.value"] = []string{strconv.FormatInt(int64(s.Named.Value), 10)}
+
+	return vals
+}
+
+// ParseFormRequest parses the form data from the request into the Parsers, in the
+// same way as form.Process.
+func (s *Parsers) ParseFormRequest(r *http.Request) error {
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+
+	var (
+		errs  form.ErrorMap
+		query url.Values
+		val   []string
+		ok    bool
+	)
+
+	if r.URL != nil {
+		query = r.URL.Query()
+	}
+
+	val, ok = r.Form["upper"]
+
+	if ok {
+		if err := s.Upper.ParseForm(val); err != nil {
+			errs = form.AddError(errs, "upper", err)
+		}
+	} else {
+		errs = form.AddError(errs, "upper", form.ErrRequiredMissing)
+	}
+
+	val, ok = r.Form["words"]
+
+	if !ok {
+		val, ok = []string{"a b", "c"}, true
+	}
+
+	if ok {
+		if err := s.Words.ParseForm(val); err != nil {
+			errs = form.AddError(errs, "words", err)
+		}
+	}
+
+	val, ok = r.Form["uppers"]
+
+	if ok {
+		if err := form.CheckCount(len(val), 0, 2); err != nil {
+			errs = form.AddError(errs, "uppers", err)
+		} else {
+			if cap(s.Uppers) >= len(val) {
+				s.Uppers = s.Uppers[:len(val)]
+			} else {
+				s.Uppers = make([]Upper, len(val))
+			}
+
+			var es form.Errors
+
+			for n := range val {
+				if err := s.Uppers[n].ParseForm(val[n:]); err != nil {
+					if es == nil {
+						es = make(form.Errors, len(val))
+					}
+
+					es[n] = err
+				}
+			}
+
+			if es != nil {
+				errs = form.AddError(errs, "uppers", es)
+			}
+		}
+	}
+
+	val, ok = query["query"]
+
+	if ok {
+		if err := s.Query.ParseForm(val); err != nil {
+			errs = form.AddError(errs, "query", err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// EncodeForm encodes the Parsers into form values, in the same way as
+// form.Encode.
+func (s Parsers) EncodeForm() url.Values {
+	vals := make(url.Values)
+
+	vals["upper"] = []string{string(s.Upper)}
+
+	if s.Words != nil {
+		if data := s.Words.FormatForm(); len(data) > 0 {
+			vals["words"] = data
+		}
+	}
+
+	if len(s.Uppers) > 0 {
+		data := make([]string, len(s.Uppers))
+
+		for n, e := range s.Uppers {
+			data[n] = string(e)
+		}
+
+		vals["uppers"] = data
+	}
+
+	if data := s.Query.FormatForm(); len(data) > 0 {
+		vals["query"] = data
+	}
+
+	return vals
+}
@@ -0,0 +1,388 @@
+package form
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errorCodes = [...]struct {
+	err  error
+	code string
+}{
+	{ErrRequiredMissing, "required"},
+	{ErrConflict, "conflict"},
+	{ErrUnknownKey, "unknown"},
+	{ErrNotInRange, "range"},
+	{ErrNoMatch, "pattern"},
+	{ErrInvalidChoice, "choice"},
+	{ErrTooShort, "too_short"},
+	{ErrTooLong, "too_long"},
+	{ErrTooFew, "too_few"},
+	{ErrTooMany, "too_many"},
+	{ErrInvalidIndex, "index"},
+	{ErrInvalidKey, "key"},
+	{ErrTooManyKeys, "too_many_keys"},
+	{ErrFileTooLarge, "file_too_large"},
+	{ErrTooManyFiles, "too_many_files"},
+	{ErrInvalidType, "content_type"},
+}
+
+// ErrorCode returns a stable code for the given error, which is one of the
+// following:
+//
+//	required, conflict, unknown, range, pattern, choice, too_short, too_long,
+//	too_few, too_many, index, key, too_many_keys, file_too_large,
+//	too_many_files, content_type, parse, invalid
+//
+// The 'parse' code is returned for a *ParseError, and 'invalid' for any error
+// not otherwise recognised, such as those returned from a ParseForm method.
+func ErrorCode(err error) string {
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return ec.code
+		}
+	}
+
+	var pe *ParseError
+
+	if errors.As(err, &pe) {
+		return "parse"
+	}
+
+	return "invalid"
+}
+
+type jsonError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func toJSON(err error) interface{} {
+	switch err.(type) {
+	case nil:
+		return nil
+	case Errors, ErrorMap:
+		return err
+	}
+
+	return jsonError{
+		Code:    ErrorCode(err),
+		Message: err.Error(),
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the errors as
+// an array, with each error being an object containing a 'code', as returned
+// by ErrorCode, and a 'message', and with nil errors encoded as null.
+func (e Errors) MarshalJSON() ([]byte, error) {
+	errs := make([]interface{}, len(e))
+
+	for n, err := range e {
+		errs[n] = toJSON(err)
+	}
+
+	return json.Marshal(errs)
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the errors as
+// an object keyed by the form keys, with each error encoded as for Errors.
+func (e ErrorMap) MarshalJSON() ([]byte, error) {
+	errs := make(map[string]interface{}, len(e))
+
+	for key, err := range e {
+		errs[key] = toJSON(err)
+	}
+
+	return json.Marshal(errs)
+}
+
+// InvalidParam is an entry of the 'invalid-params' list of a Problem.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// Problem is an RFC 7807 problem details object describing the errors returned
+// from processing form data.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// Problem creates a Problem from an error returned from processing into fv,
+// with messages in the default language of the Translator, if one is set.
+func (d *Decoder) Problem(fv interface{}, err error) Problem {
+	return d.ProblemIn(fv, err, "")
+}
+
+// ProblemIn creates a Problem from an error returned from processing into fv,
+// with the messages translated using the Translator set on the Decoder.
+//
+// The lang param can be a single language tag, such as 'fr', or a list in the
+// format of the Accept-Language header, in which case the languages are tried
+// in order of preference for each message. When none of the languages are
+// translated, the Translator is called with an empty language, and, if that
+// fails, the message of the error is used. A message set with the 'msg' tag of
+// a field overrides all others.
+//
+// When the error is an ErrorMap, each error is added to the invalid params,
+// named with the full key of the field, such as 'items[0].qty' or
+// 'prefs[colour]', and with the errors for slices of basic types named with
+// the index of the value, such as 'tags[2]'. The params are ordered by the
+// declaration order of the fields of fv, with the entries for a slice or map
+// being ordered by index or key.
+func (d *Decoder) ProblemIn(fv interface{}, err error, lang string) Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusBadRequest),
+		Status: http.StatusBadRequest,
+	}
+
+	em, ok := err.(ErrorMap)
+	if !ok {
+		if err != nil {
+			p.Detail = err.Error()
+		}
+
+		return p
+	}
+
+	var tm typeMap
+
+	if t := reflect.TypeOf(fv); t != nil {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() == reflect.Struct {
+			tm, _ = d.getTypeMap(t)
+		}
+	}
+
+	p.Detail = "errors occurred during processing form data"
+
+	l := localiser{
+		Decoder: d,
+		langs:   append(parseAcceptLanguage(lang), ""),
+	}
+
+	p.InvalidParams = tm.invalidParams(l, "", em, nil)
+
+	return p
+}
+
+// WriteProblem writes the Problem created from the given error to the
+// ResponseWriter, with the 'application/problem+json' content type.
+func (d *Decoder) WriteProblem(w http.ResponseWriter, fv interface{}, err error) error {
+	return d.WriteProblemIn(w, fv, err, "")
+}
+
+// WriteProblemIn acts like WriteProblem, but translates the messages as with
+// ProblemIn.
+func (d *Decoder) WriteProblemIn(w http.ResponseWriter, fv interface{}, err error, lang string) error {
+	p := d.ProblemIn(fv, err, lang)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+
+	return json.NewEncoder(w).Encode(p)
+}
+
+// WriteProblem writes an RFC 7807 problem details response for an error
+// returned from Process, using the default Decoder.
+//
+// See the Problem method of Decoder for details.
+func WriteProblem(w http.ResponseWriter, fv interface{}, err error) error {
+	return defaultDecoder.WriteProblem(w, fv, err)
+}
+
+// WriteProblemIn acts like WriteProblem, but uses the given language, which
+// can be the value of an Accept-Language header, when translating messages.
+//
+// As the default Decoder has no Translator, only messages set with the 'msg'
+// tag differ from those of the errors.
+func WriteProblemIn(w http.ResponseWriter, fv interface{}, err error, lang string) error {
+	return defaultDecoder.WriteProblemIn(w, fv, err, lang)
+}
+
+func (tm typeMap) invalidParams(l localiser, prefix string, errs ErrorMap, params []InvalidParam) []InvalidParam {
+	for _, key := range tm.errorKeys(errs) {
+		err, pd := errs[key], tm[key]
+
+		if s, ok := pd.Keys.(structSlice); ok {
+			if rows, ok := err.(Errors); ok {
+				if etm, terr := l.getTypeMap(s.typ.Elem()); terr == nil {
+					for n, row := range rows {
+						name := prefix + key + "[" + strconv.Itoa(n) + "]"
+
+						if em, ok := row.(ErrorMap); ok {
+							params = etm.invalidParams(l, name+".", em, params)
+						} else if row != nil {
+							params = l.appendParams(params, name, pd.Message, row)
+						}
+					}
+
+					continue
+				}
+			}
+		}
+
+		params = l.appendParams(params, prefix+key, pd.Message, err)
+	}
+
+	return params
+}
+
+type localiser struct {
+	*Decoder
+	langs []string
+}
+
+func (l localiser) appendParams(params []InvalidParam, name, msg string, err error) []InvalidParam {
+	switch err := err.(type) {
+	case nil:
+	case Errors:
+		for n, e := range err {
+			params = l.appendParams(params, name+"["+strconv.Itoa(n)+"]", msg, e)
+		}
+	case ErrorMap:
+		for _, key := range err.Keys() {
+			params = l.appendParams(params, name+"["+key+"]", msg, err[key])
+		}
+	default:
+		code := ErrorCode(err)
+
+		if msg == "" {
+			msg = l.message(name, code, err)
+		}
+
+		params = append(params, InvalidParam{
+			Name:   name,
+			Code:   code,
+			Reason: msg,
+		})
+	}
+
+	return params
+}
+
+func (l localiser) message(name, code string, err error) string {
+	if l.translator != nil {
+		params := errorParams(err)
+		params["name"] = name
+
+		for _, lang := range l.langs {
+			if msg, ok := l.translator.Translate(lang, code, params); ok {
+				return msg
+			}
+		}
+	}
+
+	return err.Error()
+}
+
+func errorParams(err error) map[string]interface{} {
+	params := make(map[string]interface{})
+
+	var (
+		re *RangeError
+		pe *PatternError
+		ce *ChoiceError
+		le *LengthError
+		co *CountError
+		pa *ParseError
+	)
+
+	switch {
+	case errors.As(err, &re):
+		if re.Min != nil {
+			params["min"] = re.Min
+		}
+
+		if re.Max != nil {
+			params["max"] = re.Max
+		}
+
+		params["value"] = re.Value
+	case errors.As(err, &pe):
+		params["pattern"] = pe.Pattern
+		params["value"] = pe.Value
+	case errors.As(err, &ce):
+		params["choices"] = ce.Choices
+		params["value"] = ce.Value
+	case errors.As(err, &le):
+		if le.Min > 0 {
+			params["min"] = le.Min
+		}
+
+		if le.Max > 0 {
+			params["max"] = le.Max
+		}
+
+		params["length"] = le.Len
+	case errors.As(err, &co):
+		if co.Min > 0 {
+			params["min"] = co.Min
+		}
+
+		if co.Max > 0 {
+			params["max"] = co.Max
+		}
+
+		params["count"] = co.Count
+	case errors.As(err, &pa):
+		params["value"] = pa.Value
+		params["error"] = pa.Err
+	}
+
+	return params
+}
+
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+
+	var langs []language
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		if q > 0 {
+			langs = append(langs, language{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	tags := make([]string, len(langs))
+
+	for n, lang := range langs {
+		tags[n] = lang.tag
+	}
+
+	return tags
+}
@@ -0,0 +1,183 @@
+package form
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	for n, test := range [...]struct {
+		Err  error
+		Code string
+	}{
+		{ErrRequiredMissing, "required"},
+		{&RangeError{Value: 1}, "range"},
+		{&PatternError{}, "pattern"},
+		{&ChoiceError{}, "choice"},
+		{&ParseError{Err: ErrInvalidBoolean}, "parse"},
+		{ErrTooManyFiles, "too_many_files"},
+		{errors.New("custom"), "invalid"},
+	} {
+		if code := ErrorCode(test.Err); code != test.Code {
+			t.Errorf("test %d: expecting code %q, got %q", n+1, test.Code, code)
+		}
+	}
+}
+
+func TestErrorMapMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(ErrorMap{
+		"A": ErrRequiredMissing,
+		"B": Errors{
+			nil,
+			ErrNotInRange,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"A":{"code":"required","message":"required field is missing"},"B":[null,{"code":"range","message":"value not in valid range"}]}`
+
+	if string(data) != expected {
+		t.Errorf("expecting %s, got %s", expected, data)
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	var output struct {
+		Z    int            `form:"z,required"`
+		A    []int          `form:"a" max:"5"`
+		Rows []Row          `form:"rows"`
+		M    map[string]int `form:"m"`
+	}
+
+	err := ProcessValues(url.Values{
+		"a":            []string{"1", "10"},
+		"rows[1].qty":  []string{"11"},
+		"rows[0].name": []string{"Alice"},
+		"m[x]":         []string{"y"},
+	}, &output)
+
+	w := httptest.NewRecorder()
+
+	if err := WriteProblem(w, &output, err); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if w.Code != 400 {
+		t.Errorf("expecting status 400, got %d", w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expecting problem content type, got %q", ct)
+	}
+
+	var p Problem
+
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var names, codes []string
+
+	for _, ip := range p.InvalidParams {
+		names = append(names, ip.Name)
+		codes = append(codes, ip.Code)
+	}
+
+	if expected := []string{"z", "a[1]", "rows[1].name", "rows[1].qty", "m[x]"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expecting names %v, got %v", expected, names)
+	}
+
+	if expected := []string{"required", "range", "required", "range", "parse"}; !reflect.DeepEqual(codes, expected) {
+		t.Errorf("expecting codes %v, got %v", expected, codes)
+	}
+}
+
+type testTranslator map[string]map[string]string
+
+func (t testTranslator) Translate(lang, code string, params map[string]interface{}) (string, bool) {
+	if lang == "" {
+		lang = "en"
+	}
+
+	msgs, ok := t[lang]
+	if !ok {
+		return "", false
+	}
+
+	msg, ok := msgs[code]
+	if !ok {
+		return "", false
+	}
+
+	return strings.NewReplacer("{name}", fmt.Sprint(params["name"]), "{max}", fmt.Sprint(params["max"])).Replace(msg), true
+}
+
+func TestProblemIn(t *testing.T) {
+	type input struct {
+		A int    `form:"a" max:"5"`
+		B string `form:"b,required"`
+		C string `form:"c,required" msg:"please fill in C"`
+		D string `form:"d" maxlen:"3"`
+		E []int  `form:"e" maxcount:"1"`
+	}
+
+	d := NewDecoder(Translation(testTranslator{
+		"en": {
+			"range":    "{name} must be at most {max}",
+			"too_long": "{name} must be at most {max} characters",
+			"too_many": "{name} must have at most {max} values",
+		},
+		"fr": {
+			"range":    "{name} doit être au plus {max}",
+			"required": "{name} est obligatoire",
+		},
+	}))
+
+	var output input
+
+	err := d.ProcessValues(url.Values{
+		"a": []string{"10"},
+		"d": []string{"abcd"},
+		"e": []string{"1", "2"},
+	}, &output)
+
+	for n, test := range [...]struct {
+		Lang    string
+		Reasons []string
+	}{
+		{ // 1
+			Lang:    "",
+			Reasons: []string{"a must be at most 5", "required field is missing", "please fill in C", "d must be at most 3 characters", "e must have at most 1 values"},
+		},
+		{ // 2
+			Lang:    "fr",
+			Reasons: []string{"a doit être au plus 5", "b est obligatoire", "please fill in C", "d must be at most 3 characters", "e must have at most 1 values"},
+		},
+		{ // 3
+			Lang:    "de-DE, fr;q=0.8, en;q=0.9",
+			Reasons: []string{"a must be at most 5", "b est obligatoire", "please fill in C", "d must be at most 3 characters", "e must have at most 1 values"},
+		},
+		{ // 4
+			Lang:    "de, fr;q=0.5, en;q=0",
+			Reasons: []string{"a doit être au plus 5", "b est obligatoire", "please fill in C", "d must be at most 3 characters", "e must have at most 1 values"},
+		},
+	} {
+		var reasons []string
+
+		for _, ip := range d.ProblemIn(&output, err, test.Lang).InvalidParams {
+			reasons = append(reasons, ip.Reason)
+		}
+
+		if !reflect.DeepEqual(reasons, test.Reasons) {
+			t.Errorf("test %d: expecting reasons %q, got %q", n+1, test.Reasons, reasons)
+		}
+	}
+}
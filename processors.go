@@ -1,14 +1,35 @@
 package form
 
 import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
 	"math"
+	"mime/multipart"
+	"net/http"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultMaxIndex = 1000
+	defaultMaxKeys  = 1000
 )
 
 type processor interface {
-	process(reflect.Value, []string) error
+	process(reflect.Value, []string, values) error
+	encode(*Decoder, reflect.Value) ([]string, error)
+}
+
+type keysProcessor interface {
+	processKeys(reflect.Value, string, values) (bool, error)
+	encodeKeys(*Decoder, reflect.Value, string, url.Values) error
 }
 
 type inum struct {
@@ -16,166 +37,251 @@ type inum struct {
 	bits     int
 }
 
-func newInum(tags reflect.StructTag, bits int) inum {
+func newInum(tags reflect.StructTag, bits int) (inum, error) {
 	i := inum{
 		min:  math.MinInt64,
 		max:  math.MaxInt64,
 		bits: bits,
 	}
 
+	var errs []error
+
 	if m := tags.Get("min"); m != "" {
 		if im, err := strconv.ParseInt(m, 10, bits); err == nil {
 			i.min = im
+		} else {
+			errs = append(errs, &TagError{Tag: "min", Err: err})
 		}
 	}
 
 	if m := tags.Get("max"); m != "" {
 		if im, err := strconv.ParseInt(m, 10, bits); err == nil {
 			i.max = im
+		} else {
+			errs = append(errs, &TagError{Tag: "max", Err: err})
 		}
 	}
 
-	return i
+	return i, errors.Join(errs...)
+}
+
+func (i inum) parse(data string) (int64, error) {
+	return ParseInt(data, i.bits, i.min, i.max)
 }
 
-func (i inum) process(v reflect.Value, data []string) error {
-	num, err := strconv.ParseInt(data[0], 10, i.bits)
+func (inum) set(v reflect.Value, num int64) {
+	v.SetInt(num)
+}
+
+func (i inum) process(v reflect.Value, data []string, _ values) error {
+	num, err := i.parse(data[0])
 	if err != nil {
 		return err
 	}
 
-	if num < i.min || num > i.max {
-		return ErrNotInRange
-	}
-
-	v.SetInt(num)
+	i.set(v, num)
 
 	return nil
 }
 
+func (inum) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	return []string{strconv.FormatInt(v.Int(), 10)}, nil
+}
+
 type unum struct {
 	min, max uint64
 	bits     int
 }
 
-func newUnum(tags reflect.StructTag, bits int) unum {
+func newUnum(tags reflect.StructTag, bits int) (unum, error) {
 	u := unum{
 		max:  math.MaxUint64,
 		bits: bits,
 	}
 
+	var errs []error
+
 	if m := tags.Get("min"); m != "" {
 		if um, err := strconv.ParseUint(m, 10, bits); err == nil {
 			u.min = um
+		} else {
+			errs = append(errs, &TagError{Tag: "min", Err: err})
 		}
 	}
 
 	if m := tags.Get("max"); m != "" {
 		if um, err := strconv.ParseUint(m, 10, bits); err == nil {
 			u.max = um
+		} else {
+			errs = append(errs, &TagError{Tag: "max", Err: err})
 		}
 	}
 
-	return u
+	return u, errors.Join(errs...)
+}
+
+func (u unum) parse(data string) (uint64, error) {
+	return ParseUint(data, u.bits, u.min, u.max)
+}
+
+func (unum) set(v reflect.Value, num uint64) {
+	v.SetUint(num)
 }
 
-func (u unum) process(v reflect.Value, data []string) error {
-	num, err := strconv.ParseUint(data[0], 10, u.bits)
+func (u unum) process(v reflect.Value, data []string, _ values) error {
+	num, err := u.parse(data[0])
 	if err != nil {
 		return err
 	}
 
-	if num < u.min || num > u.max {
-		return ErrNotInRange
-	}
-
-	v.SetUint(num)
+	u.set(v, num)
 
 	return nil
 }
 
+func (unum) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	return []string{strconv.FormatUint(v.Uint(), 10)}, nil
+}
+
 type float struct {
 	min, max float64
 	bits     int
 }
 
-func newFloat(tags reflect.StructTag, bits int) float {
+func newFloat(tags reflect.StructTag, bits int) (float, error) {
 	f := float{
 		min:  -math.MaxFloat64,
 		max:  math.MaxFloat64,
 		bits: bits,
 	}
 
+	var errs []error
+
 	if m := tags.Get("min"); m != "" {
 		if um, err := strconv.ParseFloat(m, bits); err == nil {
 			f.min = um
+		} else {
+			errs = append(errs, &TagError{Tag: "min", Err: err})
 		}
 	}
 
 	if m := tags.Get("max"); m != "" {
 		if um, err := strconv.ParseFloat(m, bits); err == nil {
 			f.max = um
+		} else {
+			errs = append(errs, &TagError{Tag: "max", Err: err})
 		}
 	}
 
-	return f
+	return f, errors.Join(errs...)
+}
+
+func (f float) parse(data string) (float64, error) {
+	return ParseFloat(data, f.bits, f.min, f.max)
+}
+
+func (float) set(v reflect.Value, num float64) {
+	v.SetFloat(num)
 }
 
-func (f float) process(v reflect.Value, data []string) error {
-	num, err := strconv.ParseFloat(data[0], f.bits)
+func (f float) process(v reflect.Value, data []string, _ values) error {
+	num, err := f.parse(data[0])
 	if err != nil {
 		return err
 	}
 
-	if num < f.min || num > f.max {
-		return ErrNotInRange
-	}
-
-	v.SetFloat(num)
+	f.set(v, num)
 
 	return nil
 }
 
+func (f float) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	return []string{strconv.FormatFloat(v.Float(), 'g', -1, f.bits)}, nil
+}
+
 type str struct {
-	regex *regexp.Regexp
+	regex          *regexp.Regexp
+	minLen, maxLen int
 }
 
-func newString(tags reflect.StructTag) str {
+func parseLimit(tags reflect.StructTag, tag string) (int, error) {
+	if l := tags.Get(tag); l != "" {
+		n, err := strconv.ParseUint(l, 10, 31)
+		if err != nil {
+			return 0, &TagError{Tag: tag, Err: err}
+		}
+
+		return int(n), nil
+	}
+
+	return 0, nil
+}
+
+func newString(tags reflect.StructTag) (str, error) {
+	var (
+		s    str
+		errs = make([]error, 3)
+	)
+
 	if r := tags.Get("regex"); r != "" {
 		if re, err := regexp.Compile(r); err == nil {
-			return str{
-				regex: re,
-			}
+			s.regex = re
+		} else {
+			errs[0] = &TagError{Tag: "regex", Err: err}
 		}
 	}
 
-	return str{}
+	s.minLen, errs[1] = parseLimit(tags, "minlen")
+	s.maxLen, errs[2] = parseLimit(tags, "maxlen")
+
+	return s, errors.Join(errs...)
+}
+
+func (s str) parse(data string) (string, error) {
+	return data, CheckString(data, s.minLen, s.maxLen, s.regex)
+}
+
+func (str) set(v reflect.Value, val string) {
+	v.SetString(val)
 }
 
-func (s str) process(v reflect.Value, data []string) error {
-	if s.regex != nil && !s.regex.MatchString(data[0]) {
-		return ErrNoMatch
+func (s str) process(v reflect.Value, data []string, _ values) error {
+	val, err := s.parse(data[0])
+	if err != nil {
+		return err
 	}
 
-	v.SetString(data[0])
+	s.set(v, val)
 
 	return nil
 }
 
-type boolean struct{}
+func (str) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	return []string{v.String()}, nil
+}
 
+type boolean struct {
+	trues, falses [][]byte
+}
+
+// matchString compares a case-insensitively with the lower case word b,
+// falling back to strings.EqualFold when either contains non-ASCII characters.
 func matchString(a string, b []byte) bool {
-	if len(a) != len(b) {
-		return false
-	}
+	for n := 0; n < len(a) && n < len(b); n++ {
+		c := a[n]
 
-	for n, c := range b {
-		if a[n]|32 != c {
+		if c >= utf8.RuneSelf || b[n] >= utf8.RuneSelf {
+			return strings.EqualFold(a, string(b))
+		} else if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+
+		if c != b[n] {
 			return false
 		}
 	}
 
-	return true
+	return len(a) == len(b)
 }
 
 var (
@@ -197,32 +303,331 @@ var (
 	}
 )
 
-func (boolean) process(v reflect.Value, data []string) error {
-	for _, b := range trues {
-		if matchString(data[0], b) {
-			v.SetBool(true)
+func (b boolean) process(v reflect.Value, data []string, _ values) error {
+	ts, fs := b.trues, b.falses
 
-			return nil
+	if ts == nil {
+		ts, fs = trues[:], falses[:]
+	}
+
+	t, err := parseBool(data[0], ts, fs)
+	if err != nil {
+		return err
+	}
+
+	v.SetBool(t)
+
+	return nil
+}
+
+func (b boolean) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	if b.trues == nil {
+		return []string{strconv.FormatBool(v.Bool())}, nil
+	} else if v.Bool() {
+		return []string{string(b.trues[0])}, nil
+	}
+
+	return []string{string(b.falses[0])}, nil
+}
+
+const weekLayout = "2006-W01"
+
+var defaultLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"15:04:05",
+	"15:04",
+	"2006-01",
+	weekLayout,
+}
+
+type timestamp struct {
+	layouts  []string
+	loc      *time.Location
+	min, max time.Time
+}
+
+func newTimestamp(tags reflect.StructTag) (timestamp, error) {
+	t := timestamp{
+		layouts: defaultLayouts,
+	}
+
+	var errs []error
+
+	if l := tags.Get("layout"); l != "" {
+		t.layouts = []string{l}
+	}
+
+	if tz := tags.Get("tz"); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			t.loc = loc
+		} else {
+			errs = append(errs, &TagError{Tag: "tz", Err: err})
 		}
 	}
 
-	for _, b := range falses {
-		if matchString(data[0], b) {
-			v.SetBool(false)
+	if m := tags.Get("min"); m != "" {
+		if tm, err := t.parse(m, time.UTC); err == nil {
+			t.min = tm
+		} else {
+			errs = append(errs, &TagError{Tag: "min", Err: err})
+		}
+	}
 
-			return nil
+	if m := tags.Get("max"); m != "" {
+		if tm, err := t.parse(m, time.UTC); err == nil {
+			t.max = tm
+		} else {
+			errs = append(errs, &TagError{Tag: "max", Err: err})
 		}
 	}
 
-	return ErrInvalidBoolean
+	return t, errors.Join(errs...)
+}
+
+func parseWeek(data string, loc *time.Location) (time.Time, bool) {
+	if len(data) != 8 || data[4] != '-' || data[5] != 'W' {
+		return time.Time{}, false
+	}
+
+	year, err := strconv.ParseUint(data[:4], 10, 16)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	week, err := strconv.ParseUint(data[6:], 10, 8)
+	if err != nil || week == 0 {
+		return time.Time{}, false
+	}
+
+	jan4 := time.Date(int(year), time.January, 4, 0, 0, 0, 0, loc)
+	t := jan4.AddDate(0, 0, int(week-1)*7-(int(jan4.Weekday())+6)%7)
+
+	if y, w := t.ISOWeek(); y != int(year) || w != int(week) {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+func (t timestamp) parse(data string, loc *time.Location) (time.Time, error) {
+	if t.loc != nil {
+		loc = t.loc
+	} else if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, layout := range t.layouts {
+		if layout == weekLayout {
+			if tm, ok := parseWeek(data, loc); ok {
+				return tm, nil
+			}
+		} else if tm, err := time.ParseInLocation(layout, data, loc); err == nil {
+			return tm, nil
+		}
+	}
+
+	return time.Time{}, ErrInvalidTime
+}
+
+func (t timestamp) process(v reflect.Value, data []string, vals values) error {
+	tm, err := t.parse(data[0], vals.loc)
+	if err != nil {
+		return &ParseError{Value: data[0], Err: err}
+	}
+
+	lower, upper := t.min, t.max
+
+	if t.loc == nil && vals.loc != nil {
+		lower, upper = inLocation(lower, vals.loc), inLocation(upper, vals.loc)
+	}
+
+	if !lower.IsZero() && tm.Before(lower) || !upper.IsZero() && tm.After(upper) {
+		re := &RangeError{Value: tm}
+
+		if !lower.IsZero() {
+			re.Min = lower
+		}
+
+		if !upper.IsZero() {
+			re.Max = upper
+		}
+
+		return re
+	}
+
+	v.Set(reflect.ValueOf(tm))
+
+	return nil
+}
+
+// inLocation returns the time with the same wall clock in the given location,
+// so that limits parsed in UTC apply to values parsed in another location.
+func inLocation(tm time.Time, loc *time.Location) time.Time {
+	if tm.IsZero() || loc == time.UTC {
+		return tm
+	}
+
+	return time.Date(tm.Year(), tm.Month(), tm.Day(), tm.Hour(), tm.Minute(), tm.Second(), tm.Nanosecond(), loc)
+}
+
+func (t timestamp) encode(d *Decoder, v reflect.Value) ([]string, error) {
+	tm := v.Interface().(time.Time)
+
+	if t.loc != nil {
+		tm = tm.In(t.loc)
+	} else if d.loc != nil {
+		tm = tm.In(d.loc)
+	} else {
+		tm = tm.UTC()
+	}
+
+	layout := t.layouts[0]
+
+	if len(t.layouts) > 1 {
+		if tm.Year() == 0 && tm.YearDay() == 1 {
+			layout = "15:04:05"
+		} else if tm.Hour() == 0 && tm.Minute() == 0 && tm.Second() == 0 && tm.Nanosecond() == 0 {
+			layout = "2006-01-02"
+		} else {
+			layout = "2006-01-02T15:04:05"
+		}
+
+		if tm.Nanosecond() != 0 {
+			layout += ".999999999"
+		}
+	} else if layout == weekLayout {
+		year, week := tm.ISOWeek()
+
+		return []string{fmt.Sprintf("%04d-W%02d", year, week)}, nil
+	}
+
+	return []string{tm.Format(layout)}, nil
+}
+
+type duration struct {
+	min, max time.Duration
+	unit     time.Duration
+}
+
+func newDuration(tags reflect.StructTag) (duration, error) {
+	d := duration{
+		min: math.MinInt64,
+		max: math.MaxInt64,
+	}
+
+	var errs []error
+
+	if u := tags.Get("unit"); u != "" {
+		if du, err := time.ParseDuration("1" + u); err != nil {
+			errs = append(errs, &TagError{Tag: "unit", Err: err})
+		} else if du <= 0 {
+			errs = append(errs, &TagError{Tag: "unit", Err: ErrNotInRange})
+		} else {
+			d.unit = du
+		}
+	}
+
+	if m := tags.Get("min"); m != "" {
+		if dm, err := d.parse(m); err == nil {
+			d.min = dm
+		} else {
+			errs = append(errs, &TagError{Tag: "min", Err: err})
+		}
+	}
+
+	if m := tags.Get("max"); m != "" {
+		if dm, err := d.parse(m); err == nil {
+			d.max = dm
+		} else {
+			errs = append(errs, &TagError{Tag: "max", Err: err})
+		}
+	}
+
+	return d, errors.Join(errs...)
+}
+
+func (d duration) parse(data string) (time.Duration, error) {
+	if d.unit != 0 {
+		if num, err := strconv.ParseFloat(data, 64); err == nil {
+			num *= float64(d.unit)
+			if math.IsNaN(num) {
+				return 0, strconv.ErrSyntax
+			} else if num < math.MinInt64 || num >= math.MaxInt64 {
+				return 0, &RangeError{Value: data}
+			}
+
+			return time.Duration(num), nil
+		}
+	}
+
+	return time.ParseDuration(data)
+}
+
+func (d duration) process(v reflect.Value, data []string, _ values) error {
+	dur, err := d.parse(data[0])
+	if errors.Is(err, ErrNotInRange) {
+		return err
+	} else if err != nil {
+		return &ParseError{Value: data[0], Err: err}
+	}
+
+	if dur < d.min || dur > d.max {
+		re := &RangeError{Value: dur}
+
+		if d.min != math.MinInt64 {
+			re.Min = d.min
+		}
+
+		if d.max != math.MaxInt64 {
+			re.Max = d.max
+		}
+
+		return re
+	}
+
+	v.SetInt(int64(dur))
+
+	return nil
+}
+
+func (d duration) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	dur := time.Duration(v.Int())
+
+	if d.unit != 0 {
+		return []string{strconv.FormatFloat(float64(dur)/float64(d.unit), 'f', -1, 64)}, nil
+	}
+
+	return []string{dur.String()}, nil
 }
 
 type slice struct {
 	processor
-	typ reflect.Type
+	typ                reflect.Type
+	minCount, maxCount int
+}
+
+func newSlice(tags reflect.StructTag, p processor, typ reflect.Type) (slice, error) {
+	s := slice{
+		processor: p,
+		typ:       typ,
+	}
+
+	minCount, errMin := parseLimit(tags, "mincount")
+	maxCount, errMax := parseLimit(tags, "maxcount")
+
+	s.minCount = minCount
+	s.maxCount = maxCount
+
+	return s, errors.Join(errMin, errMax)
 }
 
-func (s slice) process(v reflect.Value, data []string) error {
+func (s slice) process(v reflect.Value, data []string, vals values) error {
+	if err := CheckCount(len(data), s.minCount, s.maxCount); err != nil {
+		return err
+	}
+
 	if v.Cap() >= len(data) {
 		v.SetLen(len(data))
 	} else {
@@ -232,7 +637,7 @@ func (s slice) process(v reflect.Value, data []string) error {
 	var errs Errors
 
 	for n := range data {
-		if err := s.processor.process(v.Index(n), data[n:]); err != nil {
+		if err := s.processor.process(v.Index(n), data[n:], vals); err != nil {
 			if errs == nil {
 				errs = make(Errors, len(data))
 			}
@@ -248,15 +653,30 @@ func (s slice) process(v reflect.Value, data []string) error {
 	return nil
 }
 
+func (s slice) encode(dec *Decoder, v reflect.Value) ([]string, error) {
+	var data []string
+
+	for n := 0; n < v.Len(); n++ {
+		d, err := s.processor.encode(dec, v.Index(n))
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, d...)
+	}
+
+	return data, nil
+}
+
 type pointer struct {
 	processor
 	typ reflect.Type
 }
 
-func (p pointer) process(v reflect.Value, data []string) error {
+func (p pointer) process(v reflect.Value, data []string, vals values) error {
 	pv := reflect.New(p.typ)
 
-	if err := p.processor.process(pv.Elem(), data); err != nil {
+	if err := p.processor.process(pv.Elem(), data, vals); err != nil {
 		return err
 	}
 
@@ -265,16 +685,569 @@ func (p pointer) process(v reflect.Value, data []string) error {
 	return nil
 }
 
+func (p pointer) encode(d *Decoder, v reflect.Value) ([]string, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+
+	return p.processor.encode(d, v.Elem())
+}
+
+type structSlice struct {
+	typ      reflect.Type
+	maxIndex int
+}
+
+func newStructSlice(typ reflect.Type, tags reflect.StructTag, maxIndex int) (structSlice, error) {
+	s := structSlice{
+		typ:      typ,
+		maxIndex: maxIndex,
+	}
+
+	var errs []error
+
+	if m := tags.Get("maxindex"); m != "" {
+		if mi, err := strconv.ParseUint(m, 10, 31); err == nil {
+			s.maxIndex = int(mi)
+		} else {
+			errs = append(errs, &TagError{Tag: "maxindex", Err: err})
+		}
+	}
+
+	return s, errors.Join(errs...)
+}
+
+func parseIndex(key, prefix string) (int, string, bool) {
+	if !strings.HasPrefix(key, prefix) {
+		return 0, "", false
+	}
+
+	key = key[len(prefix):]
+
+	p := strings.IndexByte(key, ']')
+	if p <= 0 || len(key) < p+3 || key[p+1] != '.' || p > 1 && key[0] == '0' {
+		return 0, "", false
+	}
+
+	n, err := strconv.ParseUint(key[:p], 10, 31)
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); !ok || ne.Err != strconv.ErrRange {
+			return 0, "", false
+		}
+
+		n = math.MaxInt32
+	}
+
+	return int(n), key[p+2:], true
+}
+
+func rowForm(v *values) *url.Values {
+	return &v.form
+}
+
+func rowPost(v *values) *url.Values {
+	return &v.post
+}
+
+func rowQuery(v *values) *url.Values {
+	return &v.query
+}
+
+func (s structSlice) splitRows(rows []values, data url.Values, prefix string, field func(*values) *url.Values) ([]values, error) {
+	for key, val := range data {
+		n, rest, ok := parseIndex(key, prefix)
+		if !ok {
+			continue
+		} else if n > s.maxIndex {
+			return nil, ErrInvalidIndex
+		}
+
+		for len(rows) <= n {
+			rows = append(rows, values{})
+		}
+
+		row := field(&rows[n])
+
+		if *row == nil {
+			*row = make(url.Values)
+		}
+
+		(*row)[rest] = val
+	}
+
+	return rows, nil
+}
+
+func (s structSlice) processKeys(v reflect.Value, key string, vals values) (bool, error) {
+	prefix := key + "["
+
+	rows, err := s.splitRows(nil, vals.form, prefix, rowForm)
+	if err != nil {
+		return true, err
+	}
+
+	if !vals.split {
+		for n := range rows {
+			rows[n].post = rows[n].form
+			rows[n].query = rows[n].form
+		}
+	} else if rows, err = s.splitRows(rows, vals.post, prefix, rowPost); err != nil {
+		return true, err
+	} else if rows, err = s.splitRows(rows, vals.query, prefix, rowQuery); err != nil {
+		return true, err
+	}
+
+	for key, fhs := range vals.files {
+		n, rest, ok := parseIndex(key, prefix)
+		if !ok {
+			continue
+		} else if n > s.maxIndex {
+			return true, ErrInvalidIndex
+		}
+
+		for len(rows) <= n {
+			rows = append(rows, values{})
+		}
+
+		if rows[n].files == nil {
+			rows[n].files = make(map[string][]*multipart.FileHeader)
+		}
+
+		rows[n].files[rest] = fhs
+	}
+
+	if len(rows) == 0 {
+		return false, nil
+	}
+
+	ti, err := vals.dec.getTypeInfo(s.typ.Elem())
+	if err != nil {
+		return true, err
+	}
+
+	if v.Cap() >= len(rows) {
+		v.SetLen(len(rows))
+	} else {
+		v.Set(reflect.MakeSlice(s.typ, len(rows), len(rows)))
+	}
+
+	var (
+		errs Errors
+		zero = reflect.Zero(s.typ.Elem())
+	)
+
+	for n, row := range rows {
+		e := v.Index(n)
+		row.split = vals.split
+		row.dec = vals.dec
+		row.loc = vals.loc
+
+		e.Set(zero)
+
+		if row.form == nil && row.post == nil && row.query == nil && row.files == nil {
+			continue
+		}
+
+		if err := ti.validate(e, ti.process(e, row), vals.dec.alwaysValidate); len(err) > 0 {
+			prefix := key + "[" + strconv.Itoa(n) + "]."
+
+			walkParseErrors(err, func(pe *ParseError) {
+				pe.Key = prefix + pe.Key
+			})
+
+			if errs == nil {
+				errs = make(Errors, len(rows))
+			}
+
+			errs[n] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return true, errs
+	}
+
+	return true, nil
+}
+
+func (s structSlice) encodeKeys(d *Decoder, v reflect.Value, key string, vals url.Values) error {
+	tm, err := d.getTypeMap(s.typ.Elem())
+	if err != nil {
+		return err
+	}
+
+	for n := 0; n < v.Len(); n++ {
+		if err := tm.encode(d, v.Index(n), key+"["+strconv.Itoa(n)+"].", vals); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type mapping struct {
+	processor
+	typ      reflect.Type
+	keyRegex *regexp.Regexp
+	maxKeys  int
+}
+
+func newMapping(typ reflect.Type, tags reflect.StructTag, p processor, maxKeys int) (mapping, error) {
+	m := mapping{
+		processor: p,
+		typ:       typ,
+		maxKeys:   maxKeys,
+	}
+
+	var errs []error
+
+	if r := tags.Get("keyregex"); r != "" {
+		if re, err := regexp.Compile(r); err == nil {
+			m.keyRegex = re
+		} else {
+			errs = append(errs, &TagError{Tag: "keyregex", Err: err})
+		}
+	}
+
+	if mk := tags.Get("maxkeys"); mk != "" {
+		if n, err := strconv.ParseUint(mk, 10, 31); err == nil {
+			m.maxKeys = int(n)
+		} else {
+			errs = append(errs, &TagError{Tag: "maxkeys", Err: err})
+		}
+	}
+
+	return m, errors.Join(errs...)
+}
+
+func (m mapping) processKeys(v reflect.Value, key string, vals values) (bool, error) {
+	var (
+		prefix = key + "["
+		mv     reflect.Value
+		errs   ErrorMap
+		count  int
+	)
+
+	for k := range vals.form {
+		if !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "]") {
+			continue
+		}
+
+		mk := k[len(prefix) : len(k)-1]
+		if mk == "" || strings.ContainsAny(mk, "[]") || len(vals.form[k]) == 0 {
+			continue
+		}
+
+		if count++; count > m.maxKeys {
+			return true, ErrTooManyKeys
+		}
+
+		if !mv.IsValid() {
+			mv = reflect.MakeMap(m.typ)
+		}
+
+		if m.keyRegex != nil && !m.keyRegex.MatchString(mk) {
+			if errs == nil {
+				errs = make(ErrorMap)
+			}
+
+			errs[mk] = ErrInvalidKey
+
+			continue
+		}
+
+		val, _, err := vals.formValue(k)
+		if err != nil {
+			if errs == nil {
+				errs = make(ErrorMap)
+			}
+
+			errs[mk] = err
+
+			continue
+		}
+
+		e := reflect.New(m.typ.Elem()).Elem()
+
+		if err := m.processor.process(e, val, vals); err != nil {
+			walkParseErrors(err, func(pe *ParseError) {
+				pe.Key = k
+			})
+
+			if errs == nil {
+				errs = make(ErrorMap)
+			}
+
+			errs[mk] = err
+
+			continue
+		}
+
+		mv.SetMapIndex(reflect.ValueOf(mk).Convert(m.typ.Key()), e)
+	}
+
+	if !mv.IsValid() {
+		return false, nil
+	}
+
+	v.Set(mv)
+
+	if len(errs) > 0 {
+		return true, errs
+	}
+
+	return true, nil
+}
+
+func (m mapping) encodeKeys(d *Decoder, v reflect.Value, key string, vals url.Values) error {
+	iter := v.MapRange()
+
+	for iter.Next() {
+		e := reflect.New(m.typ.Elem()).Elem()
+
+		e.Set(iter.Value())
+
+		data, err := m.processor.encode(d, e)
+		if err != nil {
+			return err
+		} else if len(data) > 0 {
+			vals[key+"["+iter.Key().String()+"]"] = data
+		}
+	}
+
+	return nil
+}
+
+type file struct {
+	maxSize  int64
+	maxFiles int
+	accept   []string
+	multiple bool
+}
+
+func newFile(tags reflect.StructTag, multiple bool) (file, error) {
+	f := file{
+		maxSize:  math.MaxInt64,
+		maxFiles: math.MaxInt32,
+		multiple: multiple,
+	}
+
+	var errs []error
+
+	if m := tags.Get("maxsize"); m != "" {
+		if ms, err := strconv.ParseInt(m, 10, 64); err == nil {
+			f.maxSize = ms
+		} else {
+			errs = append(errs, &TagError{Tag: "maxsize", Err: err})
+		}
+	}
+
+	if m := tags.Get("maxfiles"); m != "" {
+		if mf, err := strconv.ParseUint(m, 10, 31); err == nil {
+			f.maxFiles = int(mf)
+		} else {
+			errs = append(errs, &TagError{Tag: "maxfiles", Err: err})
+		}
+	}
+
+	if a := tags.Get("accept"); a != "" {
+		f.accept = strings.Fields(a)
+	}
+
+	return f, errors.Join(errs...)
+}
+
+func (f file) check(fh *multipart.FileHeader) error {
+	if fh.Size > f.maxSize {
+		return ErrFileTooLarge
+	}
+
+	if len(f.accept) == 0 {
+		return nil
+	}
+
+	mf, err := fh.Open()
+	if err != nil {
+		return err
+	}
+
+	defer mf.Close()
+
+	var buf [512]byte
+
+	n, err := io.ReadFull(mf, buf[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return err
+	}
+
+	ct := http.DetectContentType(buf[:n])
+	if p := strings.IndexByte(ct, ';'); p >= 0 {
+		ct = ct[:p]
+	}
+
+	for _, a := range f.accept {
+		if a == ct || strings.HasSuffix(a, "/*") && strings.HasPrefix(ct, a[:len(a)-1]) {
+			return nil
+		}
+	}
+
+	return ErrInvalidType
+}
+
+func (f file) processKeys(v reflect.Value, key string, vals values) (bool, error) {
+	fhs := vals.files[key]
+	if len(fhs) == 0 {
+		return false, nil
+	}
+
+	if !f.multiple {
+		if err := f.check(fhs[0]); err != nil {
+			return true, err
+		}
+
+		v.Set(reflect.ValueOf(fhs[0]))
+
+		return true, nil
+	}
+
+	if len(fhs) > f.maxFiles {
+		return true, ErrTooManyFiles
+	}
+
+	var errs Errors
+
+	for n, fh := range fhs {
+		if err := f.check(fh); err != nil {
+			if errs == nil {
+				errs = make(Errors, len(fhs))
+			}
+
+			errs[n] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return true, errs
+	}
+
+	v.Set(reflect.ValueOf(fhs))
+
+	return true, nil
+}
+
+func (file) encodeKeys(*Decoder, reflect.Value, string, url.Values) error {
+	return nil
+}
+
 type formParser interface {
 	ParseForm([]string) error
 }
 
 type inter bool
 
-func (i inter) process(v reflect.Value, data []string) error {
+func (i inter) process(v reflect.Value, data []string, _ values) error {
 	if i {
 		v = v.Addr()
 	}
 
 	return v.Interface().(formParser).ParseForm(data)
 }
+
+func (i inter) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	if data, ok, err := marshal(v, formatterType); ok {
+		return data, err
+	} else if data, ok, err := marshal(v, textMarshalerType); ok {
+		return data, err
+	}
+
+	return encodeKind(v), nil
+}
+
+type text bool
+
+func (t text) process(v reflect.Value, data []string, _ values) error {
+	if t {
+		v = v.Addr()
+	}
+
+	if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(data[0])); err != nil {
+		return &ParseError{Value: data[0], Err: err}
+	}
+
+	return nil
+}
+
+func (text) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	if data, ok, err := marshal(v, textMarshalerType); ok {
+		return data, err
+	}
+
+	return encodeKind(v), nil
+}
+
+// parser is a processor of a basic type that can parse a value without setting
+// it, allowing the parsed value to be checked first.
+type parser[T comparable] interface {
+	processor
+	parse(string) (T, error)
+	set(reflect.Value, T)
+}
+
+type oneOf[T comparable] struct {
+	parser[T]
+	choices []string
+	values  []T
+}
+
+func newOneOf[T comparable](tags reflect.StructTag, p parser[T], err error) (processor, error) {
+	o := oneOf[T]{
+		parser:  p,
+		choices: strings.Fields(tags.Get("oneof")),
+	}
+
+	if len(o.choices) == 0 {
+		return p, err
+	}
+
+	errs := []error{err}
+
+	for _, c := range o.choices {
+		if v, err := p.parse(c); err == nil {
+			o.values = append(o.values, v)
+		} else {
+			errs = append(errs, &TagError{Tag: "oneof", Err: err})
+		}
+	}
+
+	return o, errors.Join(errs...)
+}
+
+func (o oneOf[T]) process(v reflect.Value, data []string, _ values) error {
+	val, err := o.parse(data[0])
+	if err != nil {
+		return err
+	} else if err := CheckChoice(val, data[0], o.choices, o.values...); err != nil {
+		return err
+	}
+
+	o.set(v, val)
+
+	return nil
+}
+
+type custom struct {
+	parse  ParserFunc
+	format FormatterFunc
+}
+
+func (c custom) process(v reflect.Value, data []string, _ values) error {
+	return c.parse(v, data)
+}
+
+func (c custom) encode(_ *Decoder, v reflect.Value) ([]string, error) {
+	if c.format == nil {
+		return encodeKind(v), nil
+	}
+
+	return c.format(v)
+}
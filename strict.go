@@ -0,0 +1,104 @@
+package form
+
+import (
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func (tm typeMap) uses(d *Decoder, key string) bool {
+	if _, ok := tm[key]; ok {
+		return true
+	}
+
+	for name, pd := range tm {
+		switch k := pd.Keys.(type) {
+		case structSlice:
+			if _, rest, ok := parseIndex(key, name+"["); ok {
+				if etm, err := d.getTypeMap(k.typ.Elem()); err == nil && etm.uses(d, rest) {
+					return true
+				}
+			}
+		case mapping:
+			if mk, ok := strings.CutPrefix(key, name+"["); ok && len(mk) > 1 && strings.HasSuffix(mk, "]") && !strings.ContainsAny(mk[:len(mk)-1], "[]") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (d *Decoder) allowedKey(key string) bool {
+	if _, ok := d.allowedKeys[key]; ok {
+		return true
+	} else if d.caseInsensitive {
+		for allowed := range d.allowedKeys {
+			if strings.EqualFold(key, allowed) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (d *Decoder) unusedKeys(tm typeMap, form url.Values, files map[string][]*multipart.FileHeader) []string {
+	var unused []string
+
+	for key := range form {
+		if !d.allowedKey(key) && !tm.uses(d, key) {
+			unused = append(unused, key)
+		}
+	}
+
+	for key := range files {
+		if _, ok := form[key]; !ok && !d.allowedKey(key) && !tm.uses(d, key) {
+			unused = append(unused, key)
+		}
+	}
+
+	sort.Strings(unused)
+
+	return unused
+}
+
+// UnusedKeys returns, in sorted order, the keys of the given values that
+// would not be processed into any field of fv, which must be a struct or a
+// pointer to a struct, ignoring any keys allowed by the Strict option.
+//
+// This can be used with the Form field of a processed http.Request to log
+// unexpected keys without rejecting the request.
+func (d *Decoder) UnusedKeys(vals url.Values, fv interface{}) ([]string, error) {
+	t := reflect.TypeOf(fv)
+	if t == nil {
+		return nil, ErrNeedStruct
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, ErrNeedStruct
+	}
+
+	tm, err := d.getTypeMap(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.caseInsensitive {
+		vals = foldValues(vals)
+	}
+
+	return d.unusedKeys(tm, vals, nil), nil
+}
+
+// UnusedKeys returns the keys of the given values that would not be processed
+// into any field of fv, using the default Decoder.
+func UnusedKeys(vals url.Values, fv interface{}) ([]string, error) {
+	return defaultDecoder.UnusedKeys(vals, fv)
+}
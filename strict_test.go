@@ -0,0 +1,136 @@
+package form
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+type strictInput struct {
+	Name  string         `form:"name"`
+	Rows  []Row          `form:"rows"`
+	Prefs map[string]int `form:"prefs"`
+	Inner struct {
+		A int `form:"a"`
+	} `form:"inner"`
+}
+
+func TestStrict(t *testing.T) {
+	input := url.Values{
+		"name":          []string{"Alice"},
+		"rows[0].name":  []string{"Bob"},
+		"rows[1].name":  []string{"Carol"},
+		"rows[1].qyt":   []string{"1"},
+		"prefs[a]":      []string{"1"},
+		"prefs[a][b]":   []string{"2"},
+		"prefs[]":       []string{"3"},
+		"inner.a":       []string{"3"},
+		"inner.b":       []string{"4"},
+		"isAdmin":       []string{"true"},
+		"csrf_token":    []string{"abc"},
+		"submit":        []string{"Save"},
+		"rows[x].name":  []string{"Dave"},
+		"Name":          []string{"Eve"},
+		"rows[0].other": []string{"5"},
+	}
+
+	for n, test := range [...]struct {
+		Options []Option
+		Err     error
+	}{
+		{ // 1
+			Options: []Option{Strict("csrf_token", "submit")},
+			Err: ErrorMap{
+				"rows[1].qyt":   ErrUnknownKey,
+				"prefs[a][b]":   ErrUnknownKey,
+				"prefs[]":       ErrUnknownKey,
+				"inner.b":       ErrUnknownKey,
+				"isAdmin":       ErrUnknownKey,
+				"rows[x].name":  ErrUnknownKey,
+				"Name":          ErrUnknownKey,
+				"rows[0].other": ErrUnknownKey,
+			},
+		},
+		{ // 2
+			Options: []Option{Strict("CSRF_Token", "submit"), CaseInsensitive()},
+			Err: ErrorMap{
+				"rows[1].qyt":   ErrUnknownKey,
+				"prefs[a][b]":   ErrUnknownKey,
+				"prefs[]":       ErrUnknownKey,
+				"inner.b":       ErrUnknownKey,
+				"isadmin":       ErrUnknownKey,
+				"rows[x].name":  ErrUnknownKey,
+				"rows[0].other": ErrUnknownKey,
+			},
+		},
+		{ // 3
+			Options: nil,
+			Err:     nil,
+		},
+	} {
+		var output strictInput
+
+		if err := NewDecoder(test.Options...).ProcessValues(input, &output); !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		}
+	}
+
+	unused, err := NewDecoder(Strict("submit")).UnusedKeys(input, strictInput{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"Name", "csrf_token", "inner.b", "isAdmin", "prefs[]", "prefs[a][b]", "rows[0].other", "rows[1].qyt", "rows[x].name"}
+
+	if !reflect.DeepEqual(unused, expected) {
+		t.Errorf("expecting unused keys %v, got %v", expected, unused)
+	}
+
+	if _, err := UnusedKeys(input, 1); err != ErrNeedStruct {
+		t.Errorf("expecting error %s, got %v", ErrNeedStruct, err)
+	}
+}
+
+func TestStrictFiles(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := multipart.NewWriter(&buf)
+
+	for _, key := range [...]string{"avatar", "rows[0].photo", "extra", "rows[0].other", "upload"} {
+		fw, _ := w.CreateFormFile(key, key)
+		io.WriteString(fw, "data")
+	}
+
+	w.WriteField("name", "Alice")
+	w.Close()
+
+	r := http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{},
+		Header: http.Header{
+			"Content-Type": []string{w.FormDataContentType()},
+		},
+		Body: io.NopCloser(&buf),
+	}
+
+	var output struct {
+		Name   string                `form:"name"`
+		Avatar *multipart.FileHeader `form:"avatar"`
+		Rows   []struct {
+			Photo *multipart.FileHeader `form:"photo"`
+		} `form:"rows"`
+	}
+
+	expected := ErrorMap{
+		"extra":         ErrUnknownKey,
+		"rows[0].other": ErrUnknownKey,
+	}
+
+	if err := NewDecoder(Strict("upload")).Process(&r, &output); !reflect.DeepEqual(err, expected) {
+		t.Errorf("expecting error %v, got %v", expected, err)
+	}
+}
@@ -0,0 +1,85 @@
+package form
+
+import (
+	"reflect"
+	"strings"
+)
+
+type formValidator interface {
+	ValidateForm() error
+}
+
+var validatorType = reflect.TypeOf((*formValidator)(nil)).Elem()
+
+type validator struct {
+	Index  []int
+	Prefix string
+	Ptr    bool
+}
+
+func (d *Decoder) validatorsFor(t reflect.Type, nested []validator) []validator {
+	if t.Implements(validatorType) {
+		return append(nested, validator{})
+	} else if reflect.PtrTo(t).Implements(validatorType) {
+		return append(nested, validator{Ptr: true})
+	}
+
+	return nested
+}
+
+func prefixValidators(vs []validator, i int, prefix string, skipSelf bool) []validator {
+	prefixed := make([]validator, 0, len(vs))
+
+	for _, v := range vs {
+		if skipSelf && len(v.Index) == 0 {
+			continue
+		}
+
+		prefixed = append(prefixed, validator{
+			Index:  append(append(make([]int, 0, len(v.Index)+1), i), v.Index...),
+			Prefix: prefix + v.Prefix,
+			Ptr:    v.Ptr,
+		})
+	}
+
+	return prefixed
+}
+
+func (ti *typeInfo) validate(v reflect.Value, errs ErrorMap, always bool) ErrorMap {
+	if len(errs) > 0 && !always {
+		return errs
+	}
+
+	for _, vd := range ti.validators {
+		fv := v.FieldByIndex(vd.Index)
+
+		if vd.Ptr {
+			if !fv.CanAddr() {
+				continue
+			}
+
+			fv = fv.Addr()
+		}
+
+		err := fv.Interface().(formValidator).ValidateForm()
+		if err == nil {
+			continue
+		}
+
+		if errs == nil {
+			errs = make(ErrorMap)
+		}
+
+		if em, ok := err.(ErrorMap); ok {
+			for key, e := range em {
+				if _, ok := errs[vd.Prefix+key]; !ok {
+					errs[vd.Prefix+key] = e
+				}
+			}
+		} else if key := strings.TrimSuffix(vd.Prefix, "."); errs[key] == nil {
+			errs[key] = err
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,134 @@
+package form
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errEndBeforeStart = errors.New("end before start")
+
+type Period struct {
+	Start time.Time `form:"start"`
+	End   time.Time `form:"end"`
+}
+
+func (p Period) ValidateForm() error {
+	if p.End.Before(p.Start) {
+		return ErrorMap{"end": errEndBeforeStart}
+	}
+
+	return nil
+}
+
+type Password struct {
+	Password string `form:"password"`
+	Confirm  string `form:"confirm"`
+}
+
+func (p *Password) ValidateForm() error {
+	if p.Password != p.Confirm {
+		return ErrNoMatch
+	}
+
+	return nil
+}
+
+type Account struct {
+	Password
+	Name   string   `form:"name" maxlen:"5"`
+	Period Period   `form:"period"`
+	Rows   []Period `form:"rows"`
+}
+
+type Signup struct {
+	Account
+	calls int
+}
+
+func (s *Signup) ValidateForm() error {
+	s.calls++
+
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	for n, test := range [...]struct {
+		Options []Option
+		Input   url.Values
+		Err     error
+	}{
+		{ // 1
+			Input: url.Values{
+				"password":   []string{"a"},
+				"confirm":    []string{"a"},
+				"period.end": []string{"2020-01-02"},
+			},
+		},
+		{ // 2
+			Input: url.Values{
+				"password":     []string{"a"},
+				"confirm":      []string{"b"},
+				"period.start": []string{"2020-01-02"},
+				"period.end":   []string{"2020-01-01"},
+			},
+			Err: ErrorMap{
+				"":           ErrNoMatch,
+				"period.end": errEndBeforeStart,
+			},
+		},
+		{ // 3
+			Input: url.Values{
+				"password":      []string{"a"},
+				"confirm":       []string{"b"},
+				"rows[1].end":   []string{"2020-01-01"},
+				"rows[1].start": []string{"2020-01-03"},
+			},
+			Err: ErrorMap{
+				"rows": Errors{
+					nil,
+					ErrorMap{"end": errEndBeforeStart},
+				},
+			},
+		},
+		{ // 4
+			Input: url.Values{
+				"password":     []string{"a"},
+				"name":         []string{"abcdef"},
+				"period.start": []string{"2020-01-02"},
+			},
+			Err: ErrorMap{
+				"name": &LengthError{Max: 5, Len: 6},
+			},
+		},
+		{ // 5
+			Options: []Option{AlwaysValidate()},
+			Input: url.Values{
+				"password":     []string{"a"},
+				"name":         []string{"abcdef"},
+				"period.start": []string{"2020-01-02"},
+			},
+			Err: ErrorMap{
+				"":           ErrNoMatch,
+				"name":       &LengthError{Max: 5, Len: 6},
+				"period.end": errEndBeforeStart,
+			},
+		},
+	} {
+		var output Account
+
+		if err := NewDecoder(test.Options...).ProcessValues(test.Input, &output); !reflect.DeepEqual(err, test.Err) {
+			t.Errorf("test %d: expecting error %v, got %v", n+1, test.Err, err)
+		}
+	}
+
+	var s Signup
+
+	if err := ProcessValues(url.Values{"password": []string{"a"}}, &s); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	} else if s.calls != 1 {
+		t.Errorf("expecting 1 call to ValidateForm, got %d", s.calls)
+	}
+}